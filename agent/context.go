@@ -24,6 +24,12 @@ type Context struct {
 	Plan           *Plan
 	Metrics        *Metrics
 	RawFinalAnswer json.RawMessage
+	// IntendedToolCalls lists the calls the model asked for during a RunOptions.PlanOnly
+	// run, in order. None of them were executed.
+	IntendedToolCalls []ToolCall
+	// ToolErrors collects every failed tool call of the run, so callers can tell a
+	// degraded result apart from a clean one even when the model still answers.
+	ToolErrors []ToolError
 }
 
 func NewContext(task string, maxSteps int) *Context {
@@ -38,6 +44,20 @@ func NewContext(task string, maxSteps int) *Context {
 func (c *Context) RecordStep(step Step) {
 	c.Steps = append(c.Steps, step)
 	c.Metrics.ToolCalls++
+	c.recordToolError(step)
+}
+
+func (c *Context) recordToolError(step Step) {
+	if step.Error == nil {
+		return
+	}
+	c.ToolErrors = append(c.ToolErrors, ToolError{Step: step.StepNumber, Tool: step.Action, Message: step.Error.Error()})
+}
+
+// recordSkippedStep records a step whose tool call was not run (plan-only or a
+// deduplicated call), so it does not count towards Metrics.ToolCalls.
+func (c *Context) recordSkippedStep(step Step) {
+	c.Steps = append(c.Steps, step)
 }
 
 func (c *Context) AddUsage(usage llm.Usage, dur time.Duration) {
@@ -72,11 +72,43 @@ func WithSkillAuthProfiles(authProfiles []string, enforce bool) Option {
 	}
 }
 
+// WithDeniedTools refuses every call to the named tools, even when they are registered
+// (including tools registered after the engine was built). The model receives a
+// refusal observation instead of a tool result.
+func WithDeniedTools(names []string) Option {
+	return func(e *Engine) {
+		for _, n := range names {
+			if n = strings.TrimSpace(n); n == "" {
+				continue
+			}
+			if e.deniedTools == nil {
+				e.deniedTools = make(map[string]bool)
+			}
+			e.deniedTools[n] = true
+		}
+	}
+}
+
 type Config struct {
 	MaxSteps       int
 	MaxTokenBudget int
 	ParseRetries   int
 	PlanMode       string // off|auto|always
+	// ValidateToolParams checks tool_params against the tool's ParameterSchema before
+	// Execute; on mismatch the model gets an error observation instead of a tool call.
+	ValidateToolParams bool
+	// DedupeToolCalls is how many times in a row a tool call identical to the previous
+	// one (same name and params) is answered from that call's result instead of running
+	// the tool again. After that the tool runs for real, so intentional polling still
+	// works. 0 disables deduplication.
+	DedupeToolCalls int
+	// ConclusionTokenBudget caps the estimated size of the force-conclusion request;
+	// the oldest non-system messages are dropped to fit. 0 disables trimming.
+	ConclusionTokenBudget int
+	// ReasoningTokenBudget caps reasoning/thinking tokens per LLM call, separately from
+	// MaxTokenBudget, so long reasoning doesn't starve the final answer. It is sent as
+	// llm.ParamReasoningTokenBudget (the openai provider maps it to reasoning_effort). 0 disables.
+	ReasoningTokenBudget int
 }
 
 type Engine struct {
@@ -96,6 +128,8 @@ type Engine struct {
 	skillAuthProfiles []string
 	enforceSkillAuth  bool
 
+	deniedTools map[string]bool
+
 	guard *guard.Guard
 }
 
@@ -129,13 +163,26 @@ func New(client llm.Client, registry *tools.Registry, cfg Config, spec PromptSpe
 }
 
 func (e *Engine) Run(ctx context.Context, task string, opts RunOptions) (*Final, *Context, error) {
-	agentCtx := NewContext(task, e.config.MaxSteps)
+	maxSteps := e.config.MaxSteps
+	if opts.MaxSteps > 0 {
+		maxSteps = opts.MaxSteps
+	}
+	tokenBudget := e.config.MaxTokenBudget
+	if opts.MaxTokenBudget > 0 {
+		tokenBudget = opts.MaxTokenBudget
+	}
+	agentCtx := NewContext(task, maxSteps)
 	ctx = secrets.WithSkillAuthProfilePolicy(ctx, e.skillAuthProfiles, e.enforceSkillAuth)
+	ctx = WithMeta(ctx, opts.Meta)
 
 	model := strings.TrimSpace(opts.Model)
 	if model == "" {
 		model = "gpt-4o-mini"
 	}
+	schema, err := parseFinalSchema(opts.FinalSchema)
+	if err != nil {
+		return nil, agentCtx, err
+	}
 
 	runID := newRunID()
 	log := e.log.With("run_id", runID, "model", model)
@@ -148,8 +195,15 @@ func (e *Engine) Run(ctx context.Context, task string, opts RunOptions) (*Final,
 		systemPrompt = BuildSystemPrompt(e.registry, e.spec)
 	}
 
+	history := opts.History
+	if opts.Session != nil {
+		prevPlan, prevHistory := opts.Session.snapshot()
+		agentCtx.Plan = prevPlan
+		history = append(prevHistory, opts.History...)
+	}
+
 	messages := []llm.Message{{Role: "system", Content: systemPrompt}}
-	for _, m := range opts.History {
+	for _, m := range history {
 		if strings.TrimSpace(strings.ToLower(m.Role)) == "system" {
 			continue
 		}
@@ -164,7 +218,21 @@ func (e *Engine) Run(ctx context.Context, task string, opts RunOptions) (*Final,
 		log.Debug("run_meta_injected", "meta_bytes", len(metaMsg))
 	}
 
+	if planMsg, ok := sessionPlanMessage(agentCtx.Plan); ok {
+		messages = append(messages, llm.Message{Role: "user", Content: planMsg})
+	}
+
 	messages = append(messages, llm.Message{Role: "user", Content: task})
+	if schema != nil {
+		messages = append(messages, llm.Message{Role: "user", Content: finalSchemaInstruction(opts.FinalSchema)})
+	}
+	if opts.PlanOnly {
+		messages = append(messages, llm.Message{
+			Role: "user",
+			Content: "This is a plan-only (dry) run: tool calls will NOT be executed. " +
+				"Request the tool calls you would make, one per step, then give a final answer describing what the run would do.",
+		})
+	}
 
 	requestedWrites := ExtractFileWritePaths(task)
 
@@ -194,18 +262,27 @@ func (e *Engine) Run(ctx context.Context, task string, opts RunOptions) (*Final,
 	if e.paramsBuilder != nil {
 		extraParams = e.paramsBuilder(opts)
 	}
+	extraParams = withReasoningBudget(extraParams, e.config.ReasoningTokenBudget)
 
-	return e.runLoop(ctx, &engineLoopState{
+	final, runCtx, err := e.runLoop(ctx, &engineLoopState{
 		runID:           runID,
 		model:           model,
 		log:             log,
 		messages:        messages,
 		agentCtx:        agentCtx,
 		extraParams:     extraParams,
+		tokenBudget:     tokenBudget,
+		planOnly:        opts.PlanOnly,
 		planRequired:    planRequired,
 		requestedWrites: requestedWrites,
+		finalSchemaRaw:  opts.FinalSchema,
+		finalSchema:     schema,
 		nextStep:        0,
 	})
+	if err == nil && opts.Session != nil {
+		opts.Session.record(task, final, runCtx)
+	}
+	return final, runCtx, err
 }
 
 func (e *Engine) loadedSkillNames() map[string]bool {
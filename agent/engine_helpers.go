@@ -3,20 +3,32 @@ package agent
 import (
 	"context"
 	"log/slog"
+	"sort"
 	"strings"
 
 	"github.com/quailyquaily/mistermorph/llm"
 )
 
-func (e *Engine) forceConclusion(ctx context.Context, messages []llm.Message, model string, agentCtx *Context, extraParams map[string]any, log *slog.Logger) (*Final, *Context, error) {
+// forceConclusion asks for a final answer once the step or token budget is spent. A
+// non-nil schema is applied as in the normal path, except that there is no room left
+// to re-prompt, so a mismatch is only logged.
+func (e *Engine) forceConclusion(ctx context.Context, messages []llm.Message, model string, agentCtx *Context, extraParams map[string]any, schema *finalSchema, log *slog.Logger) (*Final, *Context, error) {
 	if log == nil {
 		log = e.log.With("model", model)
 	}
 	log.Warn("force_conclusion", "steps", len(agentCtx.Steps), "messages", len(messages))
-	messages = append(messages, llm.Message{
+	instruction := llm.Message{
 		Role:    "user",
 		Content: "You have reached the maximum number of steps or token budget. Provide your final output NOW as a JSON final response.",
-	})
+	}
+	if budget := e.config.ConclusionTokenBudget; budget > 0 {
+		var dropped int
+		messages, dropped = trimForConclusion(messages, budget-estimateMessageTokens(instruction))
+		if dropped > 0 {
+			log.Warn("force_conclusion_trimmed", "dropped", dropped, "messages", len(messages), "budget", budget)
+		}
+	}
+	messages = append(messages, instruction)
 
 	result, err := e.client.Chat(ctx, llm.Request{
 		Model:      model,
@@ -26,37 +38,51 @@ func (e *Engine) forceConclusion(ctx context.Context, messages []llm.Message, mo
 	})
 	if err != nil {
 		log.Error("force_conclusion_llm_error", "error", err.Error())
-		if e.fallbackFinal != nil {
-			return e.fallbackFinal(), agentCtx, nil
-		}
-		return &Final{Output: "insufficient_evidence", Plan: agentCtx.Plan}, agentCtx, nil
+		return e.fallbackConclusion(agentCtx), agentCtx, nil
 	}
 	agentCtx.AddUsage(result.Usage, result.Duration)
 
 	resp, err := ParseResponse(result)
 	if err != nil {
 		log.Warn("force_conclusion_parse_error", "error", err.Error())
-		if e.fallbackFinal != nil {
-			return e.fallbackFinal(), agentCtx, nil
-		}
-		return &Final{Output: "insufficient_evidence", Plan: agentCtx.Plan}, agentCtx, nil
+		return e.fallbackConclusion(agentCtx), agentCtx, nil
 	}
 	if resp.Type != TypeFinal && resp.Type != TypeFinalAnswer {
 		log.Warn("force_conclusion_invalid_type", "type", resp.Type)
-		if e.fallbackFinal != nil {
-			return e.fallbackFinal(), agentCtx, nil
-		}
-		return &Final{Output: "insufficient_evidence", Plan: agentCtx.Plan}, agentCtx, nil
+		return e.fallbackConclusion(agentCtx), agentCtx, nil
 	}
 	agentCtx.RawFinalAnswer = resp.RawFinalAnswer
 	log.Info("force_conclusion_final")
 	fp := resp.FinalPayload()
+	if fp != nil && schema != nil {
+		out, err := schema.conformFinalOutput(fp.Output)
+		if err != nil {
+			log.Error("final_schema_mismatch", "forced", true, "error", err.Error())
+		}
+		fp.Output = out
+	}
 	if agentCtx.Plan != nil && fp != nil && fp.Plan == nil {
 		fp.Plan = agentCtx.Plan
 	}
 	return fp, agentCtx, nil
 }
 
+// fallbackConclusion is the final used when force-conclusion fails. The plan is
+// carried over so embedders can still retrieve it.
+func (e *Engine) fallbackConclusion(agentCtx *Context) *Final {
+	var f *Final
+	if e.fallbackFinal != nil {
+		f = e.fallbackFinal()
+	}
+	if f == nil {
+		f = &Final{Output: "insufficient_evidence"}
+	}
+	if f.Plan == nil && agentCtx != nil {
+		f.Plan = agentCtx.Plan
+	}
+	return f
+}
+
 func toolArgsSummary(toolName string, params map[string]any, opts LogOptions) map[string]any {
 	if len(params) == 0 {
 		return nil
@@ -86,6 +112,12 @@ func toolArgsSummary(toolName string, params map[string]any, opts LogOptions) ma
 				out["cmd"] = truncateString(strings.TrimSpace(v), 500)
 			}
 		}
+	default:
+		// Tools without a curated summary (e.g. registered by embedders) log a sanitized
+		// subset of their params, only when tool params are enabled.
+		if opts.IncludeToolParams {
+			out = genericToolArgsSummary(params, opts)
+		}
 	}
 
 	if len(out) == 0 {
@@ -93,3 +125,71 @@ func toolArgsSummary(toolName string, params map[string]any, opts LogOptions) ma
 	}
 	return out
 }
+
+// maxGenericToolArgs bounds how many top-level params genericToolArgsSummary logs.
+const maxGenericToolArgs = 20
+
+// genericToolArgsSummary returns the first maxGenericToolArgs params (by key) with
+// sensitive keys redacted and strings truncated per opts.
+func genericToolArgsSummary(params map[string]any, opts LogOptions) map[string]any {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) > maxGenericToolArgs {
+		keys = keys[:maxGenericToolArgs]
+	}
+	out := make(map[string]any, len(keys))
+	for _, k := range keys {
+		out[k] = sanitizeValue(params[k], opts.MaxStringValueChars, opts.RedactKeys, k)
+	}
+	return out
+}
+
+// estimateMessageTokens is a rough provider-independent estimate (~4 bytes per
+// token plus a small per-message overhead), good enough for trimming decisions.
+func estimateMessageTokens(m llm.Message) int {
+	return (len(m.Content)+3)/4 + 4
+}
+
+// trimForConclusion drops the oldest non-system messages until the estimated size
+// fits budget. System messages are always kept, in order. The input slice is not
+// modified; the number of dropped messages is returned.
+func trimForConclusion(messages []llm.Message, budget int) ([]llm.Message, int) {
+	total := 0
+	for _, m := range messages {
+		total += estimateMessageTokens(m)
+	}
+	if total <= budget {
+		return messages, 0
+	}
+	out := make([]llm.Message, 0, len(messages))
+	dropped := 0
+	for _, m := range messages {
+		if total > budget && m.Role != "system" {
+			total -= estimateMessageTokens(m)
+			dropped++
+			continue
+		}
+		out = append(out, m)
+	}
+	return out, dropped
+}
+
+// withReasoningBudget adds llm.ParamReasoningTokenBudget to params when budget > 0.
+// A value already set by the params builder wins. params is copied, never modified.
+func withReasoningBudget(params map[string]any, budget int) map[string]any {
+	if budget <= 0 {
+		return params
+	}
+	if _, ok := params[llm.ParamReasoningTokenBudget]; ok {
+		return params
+	}
+	out := make(map[string]any, len(params)+1)
+	for k, v := range params {
+		out[k] = v
+	}
+	out[llm.ParamReasoningTokenBudget] = budget
+	return out
+}
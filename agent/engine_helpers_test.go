@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/mistermorph/llm"
+)
+
+func TestTrimForConclusion(t *testing.T) {
+	big := strings.Repeat("x", 400) // ~104 tokens each
+	msgs := []llm.Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: "old-" + big},
+		{Role: "assistant", Content: "mid-" + big},
+		{Role: "user", Content: "new"},
+	}
+
+	if got, dropped := trimForConclusion(msgs, 10_000); dropped != 0 || len(got) != len(msgs) {
+		t.Fatalf("under budget: dropped=%d len=%d", dropped, len(got))
+	}
+
+	got, dropped := trimForConclusion(msgs, 150)
+	if dropped != 1 || len(got) != 3 {
+		t.Fatalf("dropped=%d got=%+v", dropped, got)
+	}
+	if got[0].Role != "system" || !strings.HasPrefix(got[1].Content, "mid-") || got[2].Content != "new" {
+		t.Fatalf("wrong messages kept: %+v", got)
+	}
+	if !strings.HasPrefix(msgs[1].Content, "old-") {
+		t.Fatalf("input slice was modified")
+	}
+
+	// A budget smaller than the system prompt keeps only system messages.
+	got, dropped = trimForConclusion(msgs, 1)
+	if dropped != 3 || len(got) != 1 || got[0].Role != "system" {
+		t.Fatalf("tiny budget: dropped=%d got=%+v", dropped, got)
+	}
+}
+
+func TestForceConclusion_TrimsOversizedHistory(t *testing.T) {
+	client := newMockClient(finalResponse("done"))
+	e := New(client, baseRegistry(), Config{ConclusionTokenBudget: 300}, DefaultPromptSpec())
+
+	messages := []llm.Message{{Role: "system", Content: "system prompt"}}
+	for i := 0; i < 20; i++ {
+		messages = append(messages, llm.Message{Role: "user", Content: strings.Repeat("observation ", 50)})
+	}
+
+	final, _, err := e.forceConclusion(context.Background(), messages, "m", NewContext("task", 1), nil, nil, slog.Default())
+	if err != nil {
+		t.Fatalf("forceConclusion: %v", err)
+	}
+	if final == nil || final.Output != "done" {
+		t.Fatalf("final = %+v", final)
+	}
+
+	calls := client.allCalls()
+	if len(calls) != 1 {
+		t.Fatalf("calls = %d", len(calls))
+	}
+	sent := calls[0].Messages
+	if len(sent) >= len(messages)+1 {
+		t.Fatalf("history not trimmed: sent %d messages", len(sent))
+	}
+	if sent[0].Role != "system" || sent[0].Content != "system prompt" {
+		t.Fatalf("system prompt not preserved: %+v", sent[0])
+	}
+	if last := sent[len(sent)-1]; !strings.Contains(last.Content, "Provide your final output NOW") {
+		t.Fatalf("final instruction missing: %+v", last)
+	}
+	total := 0
+	for _, m := range sent {
+		total += estimateMessageTokens(m)
+	}
+	if total > 300 {
+		t.Fatalf("estimated tokens = %d, want <= 300", total)
+	}
+}
+
+func TestToolArgsSummary_GenericFallback(t *testing.T) {
+	params := map[string]any{
+		"query":     strings.Repeat("x", 50),
+		"api_token": "sk-secret",
+		"limit":     float64(5),
+		"options":   map[string]any{"password": "hunter2", "mode": "fast"},
+	}
+	opts := DefaultLogOptions()
+	opts.MaxStringValueChars = 10
+	opts.RedactKeys = append(opts.RedactKeys, "account")
+	params["account"] = "acct-123"
+
+	if got := toolArgsSummary("crm_lookup", params, opts); got != nil {
+		t.Fatalf("without IncludeToolParams got %v, want nil", got)
+	}
+
+	opts.IncludeToolParams = true
+	got := toolArgsSummary("crm_lookup", params, opts)
+	if got["query"] != strings.Repeat("x", 10)+"...(truncated)" {
+		t.Fatalf("query = %v", got["query"])
+	}
+	if got["api_token"] != "[redacted]" || got["account"] != "[redacted]" {
+		t.Fatalf("sensitive keys not redacted: %v", got)
+	}
+	if got["limit"] != float64(5) {
+		t.Fatalf("limit = %v", got["limit"])
+	}
+	nested, _ := got["options"].(map[string]any)
+	if nested["password"] != "[redacted]" || nested["mode"] != "fast" {
+		t.Fatalf("options = %v", got["options"])
+	}
+	if params["api_token"] != "sk-secret" {
+		t.Fatalf("params were modified: %v", params)
+	}
+
+	many := make(map[string]any)
+	for i := 0; i < maxGenericToolArgs+5; i++ {
+		many[fmt.Sprintf("k%02d", i)] = i
+	}
+	if got := toolArgsSummary("custom", many, opts); len(got) != maxGenericToolArgs {
+		t.Fatalf("logged %d args, want %d", len(got), maxGenericToolArgs)
+	}
+
+	// Curated tools keep their own summaries.
+	if got := toolArgsSummary("read_file", map[string]any{"path": "a.txt", "api_key": "k"}, opts); len(got) != 1 || got["path"] != "a.txt" {
+		t.Fatalf("read_file summary = %v", got)
+	}
+}
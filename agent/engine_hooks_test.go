@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 
@@ -242,6 +243,58 @@ func TestParamsBuilder_InjectedIntoRequest(t *testing.T) {
 	}
 }
 
+func TestReasoningTokenBudget_PassedAsParameter(t *testing.T) {
+	cases := []struct {
+		name    string
+		budget  int
+		builder map[string]any
+		want    any // nil = key absent
+	}{
+		{name: "unset", budget: 0, want: nil},
+		{name: "configured", budget: 2048, want: 2048},
+		{name: "merged_with_builder", budget: 2048, builder: map[string]any{"temperature": 0.3}, want: 2048},
+		{name: "builder_wins", budget: 2048, builder: map[string]any{llm.ParamReasoningTokenBudget: 512}, want: 512},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newMockClient(finalResponse("ok"))
+			cfg := baseCfg()
+			cfg.ReasoningTokenBudget = tc.budget
+			var opts []Option
+			if tc.builder != nil {
+				opts = append(opts, WithParamsBuilder(func(RunOptions) map[string]any { return tc.builder }))
+			}
+			e := New(client, baseRegistry(), cfg, DefaultPromptSpec(), opts...)
+			if _, _, err := e.Run(context.Background(), "test task", RunOptions{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			calls := client.allCalls()
+			if len(calls) == 0 {
+				t.Fatal("expected at least one LLM call")
+			}
+			got, ok := calls[0].Parameters[llm.ParamReasoningTokenBudget]
+			if tc.want == nil {
+				if ok {
+					t.Fatalf("unexpected %s=%v", llm.ParamReasoningTokenBudget, got)
+				}
+				return
+			}
+			if got != tc.want {
+				t.Fatalf("%s = %v, want %v", llm.ParamReasoningTokenBudget, got, tc.want)
+			}
+			if _, ok := tc.builder["temperature"]; ok {
+				if calls[0].Parameters["temperature"] != 0.3 {
+					t.Fatalf("builder params lost: %v", calls[0].Parameters)
+				}
+				if _, mutated := tc.builder[llm.ParamReasoningTokenBudget]; mutated {
+					t.Fatalf("builder map was modified: %v", tc.builder)
+				}
+			}
+		})
+	}
+}
+
 func TestParamsBuilder_NilMeansNoParams(t *testing.T) {
 	client := newMockClient(finalResponse("ok"))
 	e := New(client, baseRegistry(), baseCfg(), DefaultPromptSpec())
@@ -562,3 +615,225 @@ func TestParamsBuilder_PassedToAllCalls(t *testing.T) {
 		}
 	}
 }
+
+func TestRunOptions_LimitsOverrideConfig(t *testing.T) {
+	t.Run("max_steps", func(t *testing.T) {
+		reg := baseRegistry()
+		reg.Register(&mockTool{name: "search", result: "ok"})
+		client := newMockClient(toolCallResponse("search"), toolCallResponse("search"), finalResponse("done"))
+		e := New(client, reg, Config{MaxSteps: 1, PlanMode: "off"}, DefaultPromptSpec())
+
+		final, runCtx, err := e.Run(context.Background(), "task", RunOptions{MaxSteps: 3})
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if runCtx.MaxSteps != 3 || final.Output != "done" || len(runCtx.Steps) != 2 {
+			t.Fatalf("max_steps=%d steps=%d output=%v", runCtx.MaxSteps, len(runCtx.Steps), final.Output)
+		}
+	})
+
+	withUsage := func(r llm.Result, tokens int) llm.Result {
+		r.Usage = llm.Usage{TotalTokens: tokens}
+		return r
+	}
+	cases := []struct {
+		name       string
+		cfgBudget  int
+		runBudget  int
+		wantForced bool
+	}{
+		{name: "run_budget_tighter", cfgBudget: 1000, runBudget: 10, wantForced: true},
+		{name: "run_budget_looser", cfgBudget: 10, runBudget: 1000, wantForced: false},
+		{name: "config_budget_used", cfgBudget: 10, runBudget: 0, wantForced: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := baseRegistry()
+			reg.Register(&mockTool{name: "search", result: "ok"})
+			client := newMockClient(withUsage(toolCallResponse("search"), 50), finalResponse("done"))
+			e := New(client, reg, Config{MaxSteps: 5, MaxTokenBudget: tc.cfgBudget, PlanMode: "off"}, DefaultPromptSpec())
+
+			if _, _, err := e.Run(context.Background(), "task", RunOptions{MaxTokenBudget: tc.runBudget}); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			calls := client.allCalls()
+			last := calls[len(calls)-1].Messages
+			forced := strings.Contains(last[len(last)-1].Content, "Provide your final output NOW")
+			if forced != tc.wantForced {
+				t.Fatalf("force conclusion = %v, want %v", forced, tc.wantForced)
+			}
+		})
+	}
+}
+
+type countingTool struct {
+	mockTool
+	calls int
+}
+
+func (t *countingTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	t.calls++
+	return fmt.Sprintf("%s #%d", t.result, t.calls), t.err
+}
+
+func readFileCall(path string) llm.Result {
+	return llm.Result{
+		Text: fmt.Sprintf(`{"type":"tool_call","tool_call":{"thought":"t","tool_name":"read_file","tool_params":{"path":%q}}}`, path),
+	}
+}
+
+func TestDedupeToolCalls(t *testing.T) {
+	cases := []struct {
+		name      string
+		dedupe    int
+		responses []llm.Result
+		wantCalls int
+	}{
+		{name: "disabled", dedupe: 0, responses: []llm.Result{readFileCall("a.txt"), readFileCall("a.txt")}, wantCalls: 2},
+		{name: "repeat_reused", dedupe: 1, responses: []llm.Result{readFileCall("a.txt"), readFileCall("a.txt")}, wantCalls: 1},
+		{name: "bounded", dedupe: 1, responses: []llm.Result{readFileCall("a.txt"), readFileCall("a.txt"), readFileCall("a.txt")}, wantCalls: 2},
+		{name: "different_params", dedupe: 1, responses: []llm.Result{readFileCall("a.txt"), readFileCall("b.txt")}, wantCalls: 2},
+		{name: "not_consecutive", dedupe: 1, responses: []llm.Result{readFileCall("a.txt"), readFileCall("b.txt"), readFileCall("a.txt")}, wantCalls: 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tool := &countingTool{mockTool: mockTool{name: "read_file", result: "contents"}}
+			reg := baseRegistry()
+			reg.Register(tool)
+			client := newMockClient(append(tc.responses, finalResponse("done"))...)
+			cfg := Config{MaxSteps: 10, PlanMode: "off", DedupeToolCalls: tc.dedupe}
+			e := New(client, reg, cfg, DefaultPromptSpec())
+
+			final, runCtx, err := e.Run(context.Background(), "read the file", RunOptions{})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if final.Output != "done" {
+				t.Fatalf("output = %v", final.Output)
+			}
+			if tool.calls != tc.wantCalls {
+				t.Fatalf("tool executed %d times, want %d", tool.calls, tc.wantCalls)
+			}
+			if len(runCtx.Steps) != len(tc.responses) {
+				t.Fatalf("steps = %d, want %d", len(runCtx.Steps), len(tc.responses))
+			}
+			if runCtx.Metrics.ToolCalls != tc.wantCalls {
+				t.Fatalf("ToolCalls = %d, want %d (reused calls don't count)", runCtx.Metrics.ToolCalls, tc.wantCalls)
+			}
+		})
+	}
+
+	t.Run("reused_observation", func(t *testing.T) {
+		tool := &countingTool{mockTool: mockTool{name: "read_file", result: "contents"}}
+		reg := baseRegistry()
+		reg.Register(tool)
+		client := newMockClient(readFileCall("a.txt"), readFileCall("a.txt"), finalResponse("done"))
+		e := New(client, reg, Config{MaxSteps: 10, PlanMode: "off", DedupeToolCalls: 1}, DefaultPromptSpec())
+
+		_, runCtx, err := e.Run(context.Background(), "read the file", RunOptions{})
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		second := runCtx.Steps[1].Observation
+		if !strings.Contains(second, "contents #1") || !strings.Contains(second, "reused") {
+			t.Fatalf("second observation = %q", second)
+		}
+	})
+}
+
+func TestRunOptions_PlanOnly(t *testing.T) {
+	tool := &countingTool{mockTool: mockTool{name: "read_file", result: "contents"}}
+	reg := baseRegistry()
+	reg.Register(tool)
+	client := newMockClient(readFileCall("a.txt"), toolCallResponse("read_file"), finalResponse("would read a.txt"))
+	e := New(client, reg, Config{MaxSteps: 10, PlanMode: "off"}, DefaultPromptSpec())
+
+	final, runCtx, err := e.Run(context.Background(), "write a summary to out.md", RunOptions{PlanOnly: true})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if tool.calls != 0 {
+		t.Fatalf("tool executed %d times in a plan-only run", tool.calls)
+	}
+	if final.Output != "would read a.txt" {
+		t.Fatalf("output = %v", final.Output)
+	}
+	if len(runCtx.IntendedToolCalls) != 2 || runCtx.IntendedToolCalls[0].Name != "read_file" || runCtx.IntendedToolCalls[0].Params["path"] != "a.txt" {
+		t.Fatalf("intended calls = %+v", runCtx.IntendedToolCalls)
+	}
+	if runCtx.Metrics.ToolCalls != 0 {
+		t.Fatalf("ToolCalls = %d, want 0 in a plan-only run", runCtx.Metrics.ToolCalls)
+	}
+	if !strings.Contains(runCtx.Steps[0].Observation, "Not executed") {
+		t.Fatalf("observation = %q", runCtx.Steps[0].Observation)
+	}
+	first := client.allCalls()[0].Messages
+	if !strings.Contains(first[len(first)-1].Content, "plan-only") {
+		t.Fatalf("model was not told about the plan-only run: %+v", first[len(first)-1])
+	}
+}
+
+func TestRun_RecordsToolErrors(t *testing.T) {
+	reg := baseRegistry()
+	reg.Register(&mockTool{name: "search", result: "ok"})
+	reg.Register(&mockTool{name: "fetch", err: fmt.Errorf("connection refused")})
+	client := newMockClient(toolCallResponse("search"), toolCallResponse("fetch"), toolCallResponse("missing"), finalResponse("partial answer"))
+	e := New(client, reg, Config{MaxSteps: 10, PlanMode: "off"}, DefaultPromptSpec())
+
+	final, runCtx, err := e.Run(context.Background(), "task", RunOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if final.Output != "partial answer" {
+		t.Fatalf("output = %v", final.Output)
+	}
+	want := []ToolError{
+		{Step: 1, Tool: "fetch", Message: "connection refused"},
+		{Step: 2, Tool: "missing", Message: "tool not found: missing"},
+	}
+	if len(runCtx.ToolErrors) != len(want) {
+		t.Fatalf("tool errors = %+v", runCtx.ToolErrors)
+	}
+	for i := range want {
+		if runCtx.ToolErrors[i] != want[i] {
+			t.Fatalf("tool error %d = %+v, want %+v", i, runCtx.ToolErrors[i], want[i])
+		}
+	}
+
+	// Tool errors survive a resume-state round trip.
+	restored := contextFromSnapshot(snapshotFromContext(runCtx))
+	if len(restored.ToolErrors) != len(want) || restored.ToolErrors[0] != want[0] {
+		t.Fatalf("restored tool errors = %+v", restored.ToolErrors)
+	}
+}
+
+func TestWithDeniedTools_RefusesCall(t *testing.T) {
+	bash := &countingTool{mockTool: mockTool{name: "bash", result: "ran"}}
+	reg := baseRegistry()
+	client := newMockClient(toolCallResponse("bash"), finalResponse("done"))
+	e := New(client, reg, baseCfg(), DefaultPromptSpec(), WithDeniedTools([]string{" bash ", ""}))
+	// Registered after the engine was built: still denied.
+	reg.Register(bash)
+
+	final, runCtx, err := e.Run(context.Background(), "list files", RunOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if final.Output != "done" {
+		t.Fatalf("output = %v", final.Output)
+	}
+	if bash.calls != 0 {
+		t.Fatalf("denied tool executed %d times", bash.calls)
+	}
+	if len(runCtx.Steps) != 1 || !strings.Contains(runCtx.Steps[0].Observation, "disabled by the operator") {
+		t.Fatalf("steps = %+v", runCtx.Steps)
+	}
+	if len(runCtx.ToolErrors) != 1 || runCtx.ToolErrors[0].Tool != "bash" {
+		t.Fatalf("tool errors = %+v", runCtx.ToolErrors)
+	}
+	calls := client.allCalls()
+	last := calls[len(calls)-1].Messages
+	if !strings.Contains(last[len(last)-1].Content, "disabled by the operator") {
+		t.Fatalf("model did not receive the refusal: %q", last[len(last)-1].Content)
+	}
+}
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"math/rand/v2"
@@ -18,7 +19,6 @@ import (
 // overflowing the context window on long-running multi-step runs.
 const maxObservationChars = 128 * 1024 // 128 KB
 
-
 type engineLoopState struct {
 	runID string
 	model string
@@ -27,6 +27,8 @@ type engineLoopState struct {
 	messages        []llm.Message
 	agentCtx        *Context
 	extraParams     map[string]any
+	tokenBudget     int
+	planOnly        bool
 	planRequired    bool
 	parseFailures   int
 	requestedWrites []string
@@ -34,6 +36,15 @@ type engineLoopState struct {
 	pendingTool         *pendingToolSnapshot
 	approvedPendingTool bool
 
+	// Last successful tool call, for DedupeToolCalls.
+	lastToolKey         string
+	lastToolObservation string
+	toolReuses          int
+
+	finalSchemaRaw     json.RawMessage
+	finalSchema        *finalSchema
+	finalSchemaRetried bool
+
 	nextStep int
 }
 
@@ -90,8 +101,8 @@ func (e *Engine) runLoop(ctx context.Context, st *engineLoopState) (*Final, *Con
 				"total_tokens", st.agentCtx.Metrics.TotalTokens,
 			)
 
-			if e.config.MaxTokenBudget > 0 && st.agentCtx.Metrics.TotalTokens > e.config.MaxTokenBudget {
-				log.Warn("token_budget_exceeded", "step", step, "total_tokens", st.agentCtx.Metrics.TotalTokens, "budget", e.config.MaxTokenBudget)
+			if st.tokenBudget > 0 && st.agentCtx.Metrics.TotalTokens > st.tokenBudget {
+				log.Warn("token_budget_exceeded", "step", step, "total_tokens", st.agentCtx.Metrics.TotalTokens, "budget", st.tokenBudget)
 				break
 			}
 
@@ -144,6 +155,22 @@ func (e *Engine) runLoop(ctx context.Context, st *engineLoopState) (*Final, *Con
 			st.agentCtx.RawFinalAnswer = resp.RawFinalAnswer
 			fp := resp.FinalPayload()
 			if fp != nil {
+				if st.finalSchema != nil {
+					out, err := st.finalSchema.conformFinalOutput(fp.Output)
+					if err != nil && !st.finalSchemaRetried {
+						st.finalSchemaRetried = true
+						log.Warn("final_schema_mismatch", "step", step, "retry", true, "error", err.Error())
+						st.messages = append(st.messages,
+							llm.Message{Role: "assistant", Content: result.Text},
+							llm.Message{Role: "user", Content: fmt.Sprintf("Your final output does not match the required JSON schema: %s. Respond again with type=\"final\" and an output that matches the schema exactly.", err.Error())},
+						)
+						continue
+					}
+					if err != nil {
+						log.Warn("final_schema_mismatch", "step", step, "retry", false, "error", err.Error())
+					}
+					fp.Output = out
+				}
 				if st.agentCtx.Plan != nil && fp.Plan == nil {
 					fp.Plan = st.agentCtx.Plan
 				}
@@ -156,7 +183,7 @@ func (e *Engine) runLoop(ctx context.Context, st *engineLoopState) (*Final, *Con
 					CompleteAllPlanSteps(st.agentCtx.Plan)
 				}
 
-				if len(st.requestedWrites) > 0 {
+				if len(st.requestedWrites) > 0 && !st.planOnly {
 					missing := missingFiles(st.requestedWrites)
 					if len(missing) > 0 {
 						if _, ok := e.registry.Get("write_file"); ok {
@@ -225,12 +252,38 @@ func (e *Engine) runLoop(ctx context.Context, st *engineLoopState) (*Final, *Con
 				log.Debug("tool_thought_len", "step", step, "tool", tc.Name, "thought_len", len(tc.Thought))
 			}
 
-			observation, toolErr, pausedFinal, paused := e.executeToolWithGuard(ctx, st, step, result.Text, tc, stepStart)
-			if paused {
-				return pausedFinal, st.agentCtx, nil
+			var (
+				observation string
+				toolErr     error
+				executed    bool
+			)
+			key := toolCallKey(tc)
+			if st.planOnly {
+				st.agentCtx.IntendedToolCalls = append(st.agentCtx.IntendedToolCalls, *tc)
+				observation = "Not executed: this is a plan-only run, so the call was recorded as intended instead of being run. " +
+					"Continue with the next call you would make, or give your final answer."
+				log.Info("tool_call_plan_only", "step", step, "tool", tc.Name)
+			} else if e.config.DedupeToolCalls > 0 && st.pendingTool == nil && key != "" && key == st.lastToolKey && st.toolReuses < e.config.DedupeToolCalls {
+				st.toolReuses++
+				observation = "Note: this tool call is identical to the previous one, so its result was reused instead of running the tool again. " +
+					"Change the parameters or proceed with what you have.\n\n" + st.lastToolObservation
+				log.Info("tool_call_deduped", "step", step, "tool", tc.Name, "reuses", st.toolReuses)
+			} else {
+				var pausedFinal *Final
+				var paused bool
+				observation, toolErr, pausedFinal, paused = e.executeToolWithGuard(ctx, st, step, result.Text, tc, stepStart)
+				if paused {
+					return pausedFinal, st.agentCtx, nil
+				}
+				executed = true
+				st.toolReuses = 0
+				st.lastToolKey, st.lastToolObservation = "", ""
+				if toolErr == nil {
+					st.lastToolKey, st.lastToolObservation = key, observation
+				}
 			}
 
-			st.agentCtx.RecordStep(Step{
+			recorded := Step{
 				StepNumber:  step,
 				Thought:     tc.Thought,
 				Action:      tc.Name,
@@ -238,13 +291,18 @@ func (e *Engine) runLoop(ctx context.Context, st *engineLoopState) (*Final, *Con
 				Observation: observation,
 				Error:       toolErr,
 				Duration:    time.Since(stepStart),
-			})
+			}
+			if executed {
+				st.agentCtx.RecordStep(recorded)
+			} else {
+				st.agentCtx.recordSkippedStep(recorded)
+			}
 
-			if toolErr == nil && e.onToolSuccess != nil {
+			if toolErr == nil && executed && e.onToolSuccess != nil {
 				e.onToolSuccess(st.agentCtx, tc.Name)
 			}
 
-			if toolErr == nil && st.agentCtx.Plan != nil {
+			if toolErr == nil && executed && st.agentCtx.Plan != nil {
 				completedIdx, completedStep, startedIdx, startedStep, ok := AdvancePlanOnSuccess(st.agentCtx.Plan)
 				if ok {
 					fields := []any{
@@ -298,17 +356,42 @@ func (e *Engine) runLoop(ctx context.Context, st *engineLoopState) (*Final, *Con
 		}
 	}
 
-	return e.forceConclusion(ctx, st.messages, st.model, st.agentCtx, st.extraParams, log)
+	return e.forceConclusion(ctx, st.messages, st.model, st.agentCtx, st.extraParams, st.finalSchema, log)
+}
+
+// toolCallKey identifies a tool call by name and params (map keys are sorted by
+// json.Marshal, so equal params always produce the same key).
+func toolCallKey(tc *ToolCall) string {
+	if tc == nil {
+		return ""
+	}
+	b, err := json.Marshal(tc.Params)
+	if err != nil {
+		return ""
+	}
+	return tc.Name + "\x00" + string(b)
 }
 
 func (e *Engine) executeToolWithGuard(ctx context.Context, st *engineLoopState, step int, assistantText string, tc *ToolCall, stepStart time.Time) (string, error, *Final, bool) {
 	var observation string
 	var toolErr error
 
+	if e.deniedTools[tc.Name] {
+		observation = fmt.Sprintf("Error: tool '%s' is disabled by the operator and cannot be used. Do not call it again; continue without it.", tc.Name)
+		return observation, fmt.Errorf("tool denied: disabled by the operator"), nil, false
+	}
+
 	tool, found := e.registry.Get(tc.Name)
 	if !found {
 		observation = fmt.Sprintf("Error: tool '%s' not found. Available tools: %s", tc.Name, e.registry.ToolNames())
-		return observation, fmt.Errorf("tool not found"), nil, false
+		return observation, fmt.Errorf("tool not found: %s", tc.Name), nil, false
+	}
+
+	if e.config.ValidateToolParams {
+		if err := validateToolParams(tool, tc.Params); err != nil {
+			observation = fmt.Sprintf("Error: invalid parameters for tool '%s': %s. Fix the tool_params to match the tool's parameter schema and call it again.", tc.Name, err.Error())
+			return observation, fmt.Errorf("invalid tool params: %w", err), nil, false
+		}
 	}
 
 	// Guard pre-tool decision.
@@ -321,24 +404,29 @@ func (e *Engine) executeToolWithGuard(ctx context.Context, st *engineLoopState,
 		switch gr.Decision {
 		case guard.DecisionDeny:
 			observation = fmt.Sprintf("Error: blocked by guard (%s)", strings.Join(gr.Reasons, "; "))
-			return observation, fmt.Errorf("blocked by guard"), nil, false
+			return observation, fmt.Errorf("blocked by guard: %s", strings.Join(gr.Reasons, "; ")), nil, false
 		case guard.DecisionRequireApproval:
 			if st.approvedPendingTool {
 				// Already approved; proceed.
 				break
 			}
 			// Pause run and return a pending final.
+			meta, _ := MetaFromContext(ctx)
 			rs := resumeStateV1{
-				RunID:             st.runID,
-				Model:             st.model,
-				Step:              step,
-				PlanRequired:      st.planRequired,
-				ParseFailures:     st.parseFailures,
-				SkillAuthProfiles: append([]string{}, e.skillAuthProfiles...),
-				EnforceSkillAuth:  e.enforceSkillAuth,
-				Messages:          st.messages,
-				ExtraParams:       st.extraParams,
-				AgentCtx:          snapshotFromContext(st.agentCtx),
+				RunID:              st.runID,
+				Model:              st.model,
+				Step:               step,
+				PlanRequired:       st.planRequired,
+				ParseFailures:      st.parseFailures,
+				SkillAuthProfiles:  append([]string{}, e.skillAuthProfiles...),
+				EnforceSkillAuth:   e.enforceSkillAuth,
+				Messages:           st.messages,
+				ExtraParams:        st.extraParams,
+				TokenBudget:        st.tokenBudget,
+				Meta:               meta,
+				FinalSchema:        st.finalSchemaRaw,
+				FinalSchemaRetried: st.finalSchemaRetried,
+				AgentCtx:           snapshotFromContext(st.agentCtx),
 				PendingTool: pendingToolSnapshot{
 					AssistantText: assistantText,
 					ToolCall:      *tc,
@@ -405,7 +493,7 @@ func (e *Engine) executeToolWithGuard(ctx context.Context, st *engineLoopState,
 		case guard.DecisionDeny:
 			observation = "Error: blocked by guard (tool output)"
 			if toolErr == nil {
-				toolErr = fmt.Errorf("blocked by guard")
+				toolErr = fmt.Errorf("blocked by guard: tool output")
 			}
 		}
 	}
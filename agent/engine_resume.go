@@ -51,6 +51,7 @@ func (e *Engine) Resume(ctx context.Context, approvalRequestID string) (*Final,
 	}
 
 	ctx = secrets.WithSkillAuthProfilePolicy(ctx, rs.SkillAuthProfiles, rs.EnforceSkillAuth)
+	ctx = WithMeta(ctx, rs.Meta)
 
 	// Verify action hash binding.
 	h, err := guard.ActionHash(guard.Action{
@@ -65,8 +66,17 @@ func (e *Engine) Resume(ctx context.Context, approvalRequestID string) (*Final,
 		return nil, nil, fmt.Errorf("approval action_hash mismatch (expected %s)", rec.ActionHash)
 	}
 
+	schema, err := parseFinalSchema(rs.FinalSchema)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	agentCtx := contextFromSnapshot(rs.AgentCtx)
 	log := e.log.With("run_id", rs.RunID, "model", rs.Model)
+	tokenBudget := rs.TokenBudget
+	if tokenBudget <= 0 {
+		tokenBudget = e.config.MaxTokenBudget
+	}
 
 	return e.runLoop(ctx, &engineLoopState{
 		runID:               rs.RunID,
@@ -75,9 +85,13 @@ func (e *Engine) Resume(ctx context.Context, approvalRequestID string) (*Final,
 		messages:            rs.Messages,
 		agentCtx:            agentCtx,
 		extraParams:         rs.ExtraParams,
+		tokenBudget:         tokenBudget,
 		planRequired:        rs.PlanRequired,
 		parseFailures:       rs.ParseFailures,
 		requestedWrites:     ExtractFileWritePaths(agentCtx.Task),
+		finalSchemaRaw:      rs.FinalSchema,
+		finalSchema:         schema,
+		finalSchemaRetried:  rs.FinalSchemaRetried,
 		pendingTool:         &rs.PendingTool,
 		approvedPendingTool: true,
 		nextStep:            rs.Step,
@@ -20,9 +20,15 @@ type resumeStateV1 struct {
 	SkillAuthProfiles []string `json:"skill_auth_profiles,omitempty"`
 	EnforceSkillAuth  bool     `json:"enforce_skill_auth,omitempty"`
 
-	Messages    []llm.Message   `json:"messages"`
-	ExtraParams map[string]any  `json:"extra_params,omitempty"`
-	AgentCtx    contextSnapshot `json:"agent_ctx"`
+	Messages    []llm.Message  `json:"messages"`
+	ExtraParams map[string]any `json:"extra_params,omitempty"`
+	TokenBudget int            `json:"token_budget,omitempty"`
+	Meta        map[string]any `json:"meta,omitempty"`
+
+	FinalSchema        json.RawMessage `json:"final_schema,omitempty"`
+	FinalSchemaRetried bool            `json:"final_schema_retried,omitempty"`
+
+	AgentCtx contextSnapshot `json:"agent_ctx"`
 
 	PendingTool pendingToolSnapshot `json:"pending_tool"`
 }
@@ -94,7 +100,7 @@ func contextFromSnapshot(s contextSnapshot) *Context {
 		if ss.Error != "" {
 			err = &stringError{msg: ss.Error}
 		}
-		c.Steps = append(c.Steps, Step{
+		step := Step{
 			StepNumber:  ss.StepNumber,
 			Thought:     ss.Thought,
 			Action:      ss.Action,
@@ -102,7 +108,9 @@ func contextFromSnapshot(s contextSnapshot) *Context {
 			Observation: ss.Observation,
 			Error:       err,
 			Duration:    time.Duration(ss.DurationMs) * time.Millisecond,
-		})
+		}
+		c.Steps = append(c.Steps, step)
+		c.recordToolError(step)
 	}
 	return c
 }
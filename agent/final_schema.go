@@ -0,0 +1,194 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// finalSchema is the subset of JSON Schema supported for RunOptions.FinalSchema:
+// type, properties, required, additionalProperties (bool), items and enum.
+// Other keywords are ignored.
+type finalSchema struct {
+	Type                 any                     `json:"type,omitempty"` // string or []string
+	Properties           map[string]*finalSchema `json:"properties,omitempty"`
+	Required             []string                `json:"required,omitempty"`
+	AdditionalProperties *bool                   `json:"additionalProperties,omitempty"`
+	Items                *finalSchema            `json:"items,omitempty"`
+	Enum                 []any                   `json:"enum,omitempty"`
+}
+
+func finalSchemaInstruction(raw json.RawMessage) string {
+	return "Your final output (final.output) MUST be a JSON value matching this JSON Schema:\n" + strings.TrimSpace(string(raw))
+}
+
+func parseFinalSchema(raw json.RawMessage) (*finalSchema, error) {
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return nil, nil
+	}
+	var s finalSchema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid final schema: %w", err)
+	}
+	if _, err := s.types(); err != nil {
+		return nil, fmt.Errorf("invalid final schema: %w", err)
+	}
+	return &s, nil
+}
+
+func (s *finalSchema) types() ([]string, error) {
+	switch t := s.Type.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{t}, nil
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, v := range t {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("type entries must be strings")
+			}
+			out = append(out, name)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("type must be a string or an array of strings")
+}
+
+// conformFinalOutput validates output against s. Models sometimes return structured
+// output as a JSON-encoded string; if that string parses and validates, the parsed
+// value is returned in its place.
+func (s *finalSchema) conformFinalOutput(output any) (any, error) {
+	err := s.validate("output", output)
+	if err == nil {
+		return output, nil
+	}
+	if str, ok := output.(string); ok {
+		var parsed any
+		if json.Unmarshal([]byte(strings.TrimSpace(str)), &parsed) == nil {
+			if s.validate("output", parsed) == nil {
+				return parsed, nil
+			}
+		}
+	}
+	return output, err
+}
+
+func (s *finalSchema) validate(path string, v any) error {
+	if s == nil {
+		return nil
+	}
+	types, _ := s.types()
+	if len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if jsonTypeMatches(t, v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeName(v))
+		}
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if jsonEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not one of the allowed enum values", path)
+		}
+	}
+
+	switch x := v.(type) {
+	case map[string]any:
+		for _, key := range s.Required {
+			if _, ok := x[key]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, key)
+			}
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, k)
+				}
+				continue
+			}
+			if err := child.validate(path+"."+k, x[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range x {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func jsonTypeMatches(t string, v any) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	}
+	return false
+}
+
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func jsonEqual(a, b any) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(ab) == string(bb)
+}
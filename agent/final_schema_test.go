@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/mistermorph/llm"
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+const testFinalSchema = `{
+  "type": "object",
+  "required": ["title", "score"],
+  "additionalProperties": false,
+  "properties": {
+    "title": {"type": "string"},
+    "score": {"type": "integer"},
+    "tags": {"type": "array", "items": {"type": "string"}},
+    "level": {"enum": ["low", "high"]}
+  }
+}`
+
+func finalObjectResponse(output string) llm.Result {
+	return llm.Result{Text: `{"type":"final","final":{"thought":"t","output":` + output + `}}`}
+}
+
+func TestFinalSchema_Validate(t *testing.T) {
+	s, err := parseFinalSchema(json.RawMessage(testFinalSchema))
+	if err != nil {
+		t.Fatalf("parseFinalSchema: %v", err)
+	}
+	cases := []struct {
+		name    string
+		output  string
+		wantErr string
+	}{
+		{name: "ok", output: `{"title":"a","score":3,"tags":["x"],"level":"low"}`},
+		{name: "missing_required", output: `{"title":"a"}`, wantErr: `missing required property "score"`},
+		{name: "wrong_type", output: `{"title":"a","score":1.5}`, wantErr: "output.score: expected integer"},
+		{name: "extra_property", output: `{"title":"a","score":1,"note":"x"}`, wantErr: `unexpected property "note"`},
+		{name: "bad_item", output: `{"title":"a","score":1,"tags":[1]}`, wantErr: "output.tags[0]: expected string"},
+		{name: "bad_enum", output: `{"title":"a","score":1,"level":"mid"}`, wantErr: "enum"},
+		{name: "not_object", output: `"plain text"`, wantErr: "expected object, got string"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var v any
+			if err := json.Unmarshal([]byte(tc.output), &v); err != nil {
+				t.Fatal(err)
+			}
+			_, err := s.conformFinalOutput(v)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+
+	// A JSON-encoded string output is accepted when its contents conform.
+	out, err := s.conformFinalOutput(`{"title":"a","score":2}`)
+	if err != nil {
+		t.Fatalf("string-encoded output: %v", err)
+	}
+	if m, ok := out.(map[string]any); !ok || m["title"] != "a" {
+		t.Fatalf("out = %#v, want parsed object", out)
+	}
+
+	if _, err := parseFinalSchema(json.RawMessage(`{"type": 5}`)); err == nil {
+		t.Fatalf("expected invalid schema error")
+	}
+}
+
+func TestRun_FinalSchemaRepromptsOnce(t *testing.T) {
+	client := newMockClient(
+		finalObjectResponse(`{"title":"report"}`),
+		finalObjectResponse(`{"title":"report","score":7}`),
+	)
+	e := New(client, tools.NewRegistry(), baseCfg(), DefaultPromptSpec())
+
+	final, _, err := e.Run(context.Background(), "rate it", RunOptions{FinalSchema: json.RawMessage(testFinalSchema)})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	calls := client.allCalls()
+	if len(calls) != 2 {
+		t.Fatalf("llm calls = %d, want 2 (one re-prompt)", len(calls))
+	}
+	last := calls[1].Messages[len(calls[1].Messages)-1]
+	if last.Role != "user" || !strings.Contains(last.Content, `missing required property "score"`) {
+		t.Fatalf("re-prompt message = %+v", last)
+	}
+	out, ok := final.Output.(map[string]any)
+	if !ok || out["score"] != float64(7) {
+		t.Fatalf("final output = %#v", final.Output)
+	}
+}
+
+func TestRun_FinalSchemaAcceptsAfterSecondMismatch(t *testing.T) {
+	client := newMockClient(
+		finalObjectResponse(`{"title":"x"}`),
+		finalObjectResponse(`{"title":"y"}`),
+	)
+	e := New(client, tools.NewRegistry(), baseCfg(), DefaultPromptSpec())
+
+	final, _, err := e.Run(context.Background(), "rate it", RunOptions{FinalSchema: json.RawMessage(testFinalSchema)})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n := len(client.allCalls()); n != 2 {
+		t.Fatalf("llm calls = %d, want 2", n)
+	}
+	if out, _ := final.Output.(map[string]any); out["title"] != "y" {
+		t.Fatalf("final output = %#v, want second answer accepted", final.Output)
+	}
+}
+
+func TestRun_FinalSchemaAppliedOnForcedConclusion(t *testing.T) {
+	cases := []struct {
+		name         string
+		forced       llm.Result
+		wantScore    any
+		wantMismatch bool
+	}{
+		{name: "string_encoded_conformed", forced: finalObjectResponse(`"{\"title\":\"r\",\"score\":3}"`), wantScore: float64(3)},
+		{name: "mismatch_logged", forced: finalObjectResponse(`{"title":"r"}`), wantMismatch: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			client := newMockClient(toolCallResponse("search"), tc.forced)
+			cfg := baseCfg()
+			cfg.MaxSteps = 1
+			e := New(client, tools.NewRegistry(), cfg, DefaultPromptSpec(), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+			final, _, err := e.Run(context.Background(), "rate it", RunOptions{FinalSchema: json.RawMessage(testFinalSchema)})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if n := len(client.allCalls()); n != 2 {
+				t.Fatalf("llm calls = %d, want 2 (step + forced conclusion)", n)
+			}
+			if got := strings.Contains(logs.String(), "final_schema_mismatch"); got != tc.wantMismatch {
+				t.Fatalf("final_schema_mismatch logged = %v, want %v\n%s", got, tc.wantMismatch, logs.String())
+			}
+			if tc.wantMismatch {
+				return
+			}
+			out, ok := final.Output.(map[string]any)
+			if !ok || out["score"] != tc.wantScore {
+				t.Fatalf("final output = %#v", final.Output)
+			}
+		})
+	}
+}
+
+func TestRun_InvalidFinalSchema(t *testing.T) {
+	client := newMockClient(finalResponse("ok"))
+	e := New(client, tools.NewRegistry(), baseCfg(), DefaultPromptSpec())
+	if _, _, err := e.Run(context.Background(), "x", RunOptions{FinalSchema: json.RawMessage(`{`)}); err == nil {
+		t.Fatalf("expected error for invalid schema")
+	}
+	if n := len(client.allCalls()); n != 0 {
+		t.Fatalf("llm calls = %d, want 0", n)
+	}
+}
@@ -1,10 +1,32 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 )
 
+type ctxKeyRunMeta struct{}
+
+// WithMeta attaches run metadata (RunOptions.Meta) to ctx. The engine does this for every
+// run so tools can read the trigger context (e.g. the originating chat id) in Execute.
+func WithMeta(ctx context.Context, meta map[string]any) context.Context {
+	if len(meta) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKeyRunMeta{}, meta)
+}
+
+// MetaFromContext returns the run metadata set by the engine, if any.
+// The map is shared with the run; callers must not modify it.
+func MetaFromContext(ctx context.Context) (map[string]any, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	meta, ok := ctx.Value(ctxKeyRunMeta{}).(map[string]any)
+	return meta, ok && len(meta) > 0
+}
+
 const maxInjectedMetaBytes = 4 * 1024
 
 func buildInjectedMetaMessage(meta map[string]any) (string, bool) {
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -11,7 +12,7 @@ func TestRun_InsertsMetaMessageBeforeTask(t *testing.T) {
 	client := newMockClient(finalResponse("ok"))
 	e := New(client, tools.NewRegistry(), baseCfg(), DefaultPromptSpec())
 
-	_, _, err := e.Run(t.Context(), "do the thing", RunOptions{
+	_, _, err := e.Run(context.Background(), "do the thing", RunOptions{
 		Meta: map[string]any{
 			"trigger": "cron",
 			"foo":     "bar",
@@ -47,7 +48,7 @@ func TestRun_TruncatesMetaTo4KB(t *testing.T) {
 	e := New(client, tools.NewRegistry(), baseCfg(), DefaultPromptSpec())
 
 	huge := strings.Repeat("x", 10*1024)
-	_, _, err := e.Run(t.Context(), "do the thing", RunOptions{
+	_, _, err := e.Run(context.Background(), "do the thing", RunOptions{
 		Meta: map[string]any{
 			"trigger": "cron",
 			"huge":    huge,
@@ -75,3 +76,46 @@ func TestDefaultPromptSpec_IncludesMetaRule(t *testing.T) {
 		t.Fatalf("expected DefaultPromptSpec rules to mention mister_morph_meta")
 	}
 }
+
+type metaReadingTool struct {
+	got map[string]any
+	ok  bool
+}
+
+func (t *metaReadingTool) Name() string            { return "read_meta" }
+func (t *metaReadingTool) Description() string     { return "reads run meta" }
+func (t *metaReadingTool) ParameterSchema() string { return "{}" }
+func (t *metaReadingTool) Execute(ctx context.Context, _ map[string]any) (string, error) {
+	t.got, t.ok = MetaFromContext(ctx)
+	return "ok", nil
+}
+
+func TestRun_ExposesMetaToTools(t *testing.T) {
+	cases := []struct {
+		name   string
+		meta   map[string]any
+		wantOK bool
+	}{
+		{name: "with_meta", meta: map[string]any{"trigger": "telegram", "telegram_chat_id": int64(42)}, wantOK: true},
+		{name: "without_meta", meta: nil, wantOK: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tool := &metaReadingTool{}
+			reg := tools.NewRegistry()
+			reg.Register(tool)
+			client := newMockClient(toolCallResponse("read_meta"), finalResponse("done"))
+			e := New(client, reg, baseCfg(), DefaultPromptSpec())
+
+			if _, _, err := e.Run(context.Background(), "task", RunOptions{Meta: tc.meta}); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if tool.ok != tc.wantOK {
+				t.Fatalf("MetaFromContext ok = %v, want %v", tool.ok, tc.wantOK)
+			}
+			if tc.wantOK && (tool.got["trigger"] != "telegram" || tool.got["telegram_chat_id"] != int64(42)) {
+				t.Fatalf("meta = %+v", tool.got)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package agent
+
+// GetPlan returns the plan attached to a final answer, or nil. It is safe to
+// call on a nil Final. (The Plan field itself keeps its name for JSON.)
+func (f *Final) GetPlan() *Plan {
+	if f == nil {
+		return nil
+	}
+	return f.Plan
+}
+
+// ExportedPlan is a self-contained copy of a run's plan for embedders that want
+// the plan separately from the answer.
+type ExportedPlan struct {
+	Summary    string     `json:"summary,omitempty"`
+	Steps      []PlanStep `json:"steps"`
+	Completed  int        `json:"completed"`
+	Risks      []string   `json:"risks,omitempty"`
+	Questions  []string   `json:"questions,omitempty"`
+	Completion string     `json:"completion,omitempty"`
+}
+
+// ExportPlan extracts the plan from a run result as structured steps with
+// normalized statuses. It prefers the plan on final and falls back to the run
+// context (runCtx may be nil). ok is false when the run produced no plan.
+func ExportPlan(final *Final, runCtx *Context) (ExportedPlan, bool) {
+	p := final.GetPlan()
+	if p == nil && runCtx != nil {
+		p = runCtx.Plan
+	}
+	if p == nil {
+		return ExportedPlan{}, false
+	}
+	cp := clonePlan(p)
+	NormalizePlanSteps(cp)
+	out := ExportedPlan{
+		Summary:    cp.Summary,
+		Steps:      []PlanStep(cp.Steps),
+		Risks:      cp.Risks,
+		Questions:  cp.Questions,
+		Completion: cp.Completion,
+	}
+	if out.Steps == nil {
+		out.Steps = []PlanStep{}
+	}
+	for _, s := range out.Steps {
+		if s.Status == PlanStatusCompleted {
+			out.Completed++
+		}
+	}
+	return out, true
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quailyquaily/mistermorph/llm"
+)
+
+const twoStepPlan = `{"type":"plan","plan":{"summary":"ship it","steps":[{"step":"build","status":"completed"},{"step":"deploy"}]}}`
+
+func TestPlanSurvivesForceConclusion(t *testing.T) {
+	cases := []struct {
+		name       string
+		conclusion []llm.Result // responses served to forceConclusion
+		fallback   func() *Final
+	}{
+		{name: "final_without_plan", conclusion: []llm.Result{finalResponse("partial")}},
+		{name: "llm_error_default_fallback", conclusion: nil},
+		{name: "parse_error_custom_fallback", conclusion: []llm.Result{{Text: "nope"}}, fallback: func() *Final { return &Final{Output: "fb"} }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := baseRegistry()
+			reg.Register(&mockTool{name: "search", result: "ok"})
+			responses := append([]llm.Result{{Text: twoStepPlan}, toolCallResponse("search")}, tc.conclusion...)
+			var opts []Option
+			if tc.fallback != nil {
+				opts = append(opts, WithFallbackFinal(tc.fallback))
+			}
+			// MaxSteps=2: plan + one tool call, then force conclusion.
+			e := New(newMockClient(responses...), reg, Config{MaxSteps: 2, PlanMode: "off"}, DefaultPromptSpec(), opts...)
+
+			final, runCtx, err := e.Run(context.Background(), "deploy the app", RunOptions{})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if final.GetPlan() == nil || final.GetPlan().Summary != "ship it" {
+				t.Fatalf("plan lost on force conclusion: %+v", final)
+			}
+			exp, ok := ExportPlan(final, runCtx)
+			if !ok || len(exp.Steps) != 2 || exp.Steps[0].Step != "build" {
+				t.Fatalf("ExportPlan = %+v, %v", exp, ok)
+			}
+			if exp.Steps[0].Status != PlanStatusCompleted || exp.Completed < 1 {
+				t.Fatalf("unexpected statuses: %+v", exp)
+			}
+		})
+	}
+}
+
+func TestExportPlan(t *testing.T) {
+	if _, ok := ExportPlan(nil, nil); ok {
+		t.Fatalf("expected no plan")
+	}
+	var nilFinal *Final
+	if nilFinal.GetPlan() != nil {
+		t.Fatalf("GetPlan on nil Final should be nil")
+	}
+
+	ctxPlan := &Plan{Summary: "from ctx", Steps: PlanSteps{{Step: " a "}, {Step: "b", Status: "weird"}}}
+	exp, ok := ExportPlan(&Final{Output: "x"}, &Context{Plan: ctxPlan})
+	if !ok || exp.Summary != "from ctx" {
+		t.Fatalf("ExportPlan fallback to context = %+v, %v", exp, ok)
+	}
+	if exp.Steps[0].Step != "a" || exp.Steps[0].Status != PlanStatusInProgress || exp.Steps[1].Status != PlanStatusPending {
+		t.Fatalf("steps not normalized: %+v", exp.Steps)
+	}
+	if ctxPlan.Steps[0].Step != " a " {
+		t.Fatalf("ExportPlan must not mutate the source plan")
+	}
+
+	exp, _ = ExportPlan(&Final{Plan: &Plan{Summary: "empty"}}, nil)
+	if exp.Steps == nil || len(exp.Steps) != 0 {
+		t.Fatalf("Steps should be an empty slice, got %#v", exp.Steps)
+	}
+}
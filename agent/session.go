@@ -0,0 +1,248 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/quailyquaily/mistermorph/internal/strutil"
+	"github.com/quailyquaily/mistermorph/llm"
+)
+
+const (
+	defaultSessionMaxHistory = 20
+	defaultMaxSessions       = 100
+)
+
+// Session carries state between runs of one conversation so that a follow-up
+// run continues from the previous turn instead of starting over: the latest
+// plan, the conversation history (tasks, tool calls with their results, and
+// final answers) and cumulative usage. Pass it via
+// RunOptions.Session. A Session is safe for concurrent use, but runs sharing
+// a session should be serialized by the caller to keep turns in order.
+type Session struct {
+	mu         sync.Mutex
+	maxHistory int
+	plan       *Plan
+	history    []llm.Message
+	usage      Metrics
+	turns      int
+}
+
+// NewSession returns an empty session retaining at most maxHistory messages
+// (<= 0 uses a default of 20).
+func NewSession(maxHistory int) *Session {
+	if maxHistory <= 0 {
+		maxHistory = defaultSessionMaxHistory
+	}
+	return &Session{maxHistory: maxHistory}
+}
+
+// Plan returns a copy of the plan retained from the last turn, if any.
+func (s *Session) Plan() *Plan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return clonePlan(s.plan)
+}
+
+// Usage returns metrics accumulated over all turns (LLMRounds, TotalTokens,
+// TotalCost, ToolCalls and ParseRetries).
+func (s *Session) Usage() Metrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage
+}
+
+// Turns returns the number of completed runs recorded in the session.
+func (s *Session) Turns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.turns
+}
+
+// Reset discards all retained state.
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plan = nil
+	s.history = nil
+	s.usage = Metrics{}
+	s.turns = 0
+}
+
+func (s *Session) snapshot() (*Plan, []llm.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return clonePlan(s.plan), append([]llm.Message(nil), s.history...)
+}
+
+// record folds a finished run into the session. Paused (pending approval) runs
+// are not recorded.
+func (s *Session) record(task string, final *Final, agentCtx *Context) {
+	if final == nil {
+		return
+	}
+	if _, pending := final.Output.(PendingOutput); pending {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.turns++
+	if agentCtx != nil {
+		if agentCtx.Plan != nil {
+			s.plan = clonePlan(agentCtx.Plan)
+		}
+		if m := agentCtx.Metrics; m != nil {
+			s.usage.LLMRounds += m.LLMRounds
+			s.usage.TotalTokens += m.TotalTokens
+			s.usage.TotalCost += m.TotalCost
+			s.usage.ToolCalls += m.ToolCalls
+			s.usage.ParseRetries += m.ParseRetries
+		}
+	}
+	if final.Plan != nil {
+		s.plan = clonePlan(final.Plan)
+	}
+	s.history = append(s.history, llm.Message{Role: "user", Content: task})
+	if agentCtx != nil {
+		for _, step := range agentCtx.Steps {
+			s.history = append(s.history, sessionStepMessages(step)...)
+		}
+	}
+	s.history = append(s.history, llm.Message{Role: "assistant", Content: finalOutputText(final.Output)})
+	if len(s.history) > s.maxHistory {
+		s.history = append([]llm.Message(nil), s.history[len(s.history)-s.maxHistory:]...)
+	}
+}
+
+// sessionStepMessages replays a tool step the way the engine loop sent it: the
+// assistant's tool_call followed by the tool result.
+func sessionStepMessages(step Step) []llm.Message {
+	call, err := json.Marshal(AgentResponse{
+		Type:     TypeToolCall,
+		ToolCall: &ToolCall{Thought: step.Thought, Name: step.Action, Params: step.ActionInput},
+	})
+	if err != nil {
+		return nil
+	}
+	observation := step.Observation
+	if observation == "" && step.Error != nil {
+		observation = "error: " + step.Error.Error()
+	}
+	if len(observation) > maxObservationChars {
+		observation = strutil.TruncateUTF8(observation, maxObservationChars) + "\n...(truncated)"
+	}
+	return []llm.Message{
+		{Role: "assistant", Content: string(call)},
+		{Role: "user", Content: fmt.Sprintf("Tool Result (%s):\n%s", step.Action, observation)},
+	}
+}
+
+func finalOutputText(output any) string {
+	if s, ok := output.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(output)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func sessionPlanMessage(p *Plan) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", false
+	}
+	return "Plan carried over from the previous turn (continue it and update step statuses as you go; only make a new plan if the request changed):\n" + string(b), true
+}
+
+func clonePlan(p *Plan) *Plan {
+	if p == nil {
+		return nil
+	}
+	cp := *p
+	cp.Steps = append(PlanSteps(nil), p.Steps...)
+	cp.Risks = append([]string(nil), p.Risks...)
+	cp.Questions = append([]string(nil), p.Questions...)
+	return &cp
+}
+
+// SessionStore keeps Sessions keyed by conversation, bounded to MaxSessions
+// entries; the least recently used session is dropped when the bound is hit.
+type SessionStore struct {
+	mu          sync.Mutex
+	maxSessions int
+	maxHistory  int
+	sessions    map[string]*Session
+	order       []string // least recently used first
+}
+
+// NewSessionStore returns a store holding at most maxSessions sessions, each
+// retaining at most maxHistory messages (<= 0 uses defaults).
+func NewSessionStore(maxSessions, maxHistory int) *SessionStore {
+	if maxSessions <= 0 {
+		maxSessions = defaultMaxSessions
+	}
+	return &SessionStore{
+		maxSessions: maxSessions,
+		maxHistory:  maxHistory,
+		sessions:    make(map[string]*Session),
+	}
+}
+
+// Get returns the session for key, creating it if needed.
+func (st *SessionStore) Get(key string) *Session {
+	key = strings.TrimSpace(key)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if s, ok := st.sessions[key]; ok {
+		st.touchLocked(key)
+		return s
+	}
+	s := NewSession(st.maxHistory)
+	st.sessions[key] = s
+	st.order = append(st.order, key)
+	for len(st.order) > st.maxSessions {
+		delete(st.sessions, st.order[0])
+		st.order = st.order[1:]
+	}
+	return s
+}
+
+// Reset drops the session for key; the next Get starts fresh.
+func (st *SessionStore) Reset(key string) {
+	key = strings.TrimSpace(key)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, ok := st.sessions[key]; !ok {
+		return
+	}
+	delete(st.sessions, key)
+	st.removeLocked(key)
+}
+
+// Len returns the number of retained sessions.
+func (st *SessionStore) Len() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return len(st.sessions)
+}
+
+func (st *SessionStore) touchLocked(key string) {
+	st.removeLocked(key)
+	st.order = append(st.order, key)
+}
+
+func (st *SessionStore) removeLocked(key string) {
+	for i, k := range st.order {
+		if k == key {
+			st.order = append(st.order[:i], st.order[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/mistermorph/llm"
+)
+
+func TestSession_SecondTurnSeesPriorPlanAndAccumulatesUsage(t *testing.T) {
+	plan := llm.Result{
+		Text:  `{"type":"plan","plan":{"summary":"migrate db","steps":["dump tables","restore tables"]}}`,
+		Usage: llm.Usage{TotalTokens: 10},
+	}
+	first := finalResponse("dumped")
+	first.Usage = llm.Usage{TotalTokens: 5}
+	second := finalResponse("restored")
+	second.Usage = llm.Usage{TotalTokens: 7}
+
+	client := newMockClient(plan, first, second)
+	e := New(client, baseRegistry(), baseCfg(), DefaultPromptSpec())
+	sess := NewSession(0)
+
+	if _, _, err := e.Run(context.Background(), "start the migration", RunOptions{Session: sess}); err != nil {
+		t.Fatalf("turn 1: %v", err)
+	}
+	if p := sess.Plan(); p == nil || p.Summary != "migrate db" || len(p.Steps) != 2 {
+		t.Fatalf("session plan after turn 1 = %+v", p)
+	}
+
+	final, runCtx, err := e.Run(context.Background(), "continue", RunOptions{Session: sess})
+	if err != nil {
+		t.Fatalf("turn 2: %v", err)
+	}
+	if final.Output != "restored" {
+		t.Fatalf("turn 2 output = %v", final.Output)
+	}
+	if runCtx.Plan == nil || runCtx.Plan.Summary != "migrate db" || final.Plan == nil {
+		t.Fatalf("turn 2 did not inherit the plan: ctx=%+v final=%+v", runCtx.Plan, final.Plan)
+	}
+
+	calls := client.allCalls()
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 LLM calls, got %d", len(calls))
+	}
+	var sawPlan, sawPriorTask, sawPriorAnswer bool
+	for _, m := range calls[2].Messages {
+		sawPlan = sawPlan || (strings.Contains(m.Content, "Plan carried over from the previous turn") && strings.Contains(m.Content, "dump tables"))
+		sawPriorTask = sawPriorTask || (m.Role == "user" && m.Content == "start the migration")
+		sawPriorAnswer = sawPriorAnswer || (m.Role == "assistant" && m.Content == "dumped")
+	}
+	if !sawPlan || !sawPriorTask || !sawPriorAnswer {
+		t.Fatalf("turn 2 messages missing prior state: plan=%v task=%v answer=%v", sawPlan, sawPriorTask, sawPriorAnswer)
+	}
+
+	usage := sess.Usage()
+	if usage.TotalTokens != 22 || usage.LLMRounds != 3 || sess.Turns() != 2 {
+		t.Fatalf("usage = %+v turns = %d", usage, sess.Turns())
+	}
+	if runCtx.Metrics.TotalTokens != 7 {
+		t.Fatalf("per-run metrics should not include earlier turns, got %d", runCtx.Metrics.TotalTokens)
+	}
+
+	sess.Reset()
+	if sess.Plan() != nil || sess.Turns() != 0 || sess.Usage().TotalTokens != 0 {
+		t.Fatalf("Reset did not clear session")
+	}
+}
+
+func TestSession_SecondTurnSeesPriorToolSteps(t *testing.T) {
+	reg := baseRegistry()
+	reg.Register(&mockTool{name: "search", result: "found 3 tables"})
+	client := newMockClient(toolCallResponse("search"), finalResponse("dumped"), finalResponse("restored"))
+	e := New(client, reg, baseCfg(), DefaultPromptSpec())
+	sess := NewSession(0)
+
+	if _, _, err := e.Run(context.Background(), "start the migration", RunOptions{Session: sess}); err != nil {
+		t.Fatalf("turn 1: %v", err)
+	}
+	if _, _, err := e.Run(context.Background(), "continue", RunOptions{Session: sess}); err != nil {
+		t.Fatalf("turn 2: %v", err)
+	}
+
+	calls := client.allCalls()
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 LLM calls, got %d", len(calls))
+	}
+	var roles, contents []string
+	for _, m := range calls[2].Messages[1:] {
+		roles = append(roles, m.Role)
+		contents = append(contents, m.Content)
+	}
+	want := []string{"user", "assistant", "user", "assistant", "user"}
+	if strings.Join(roles, ",") != strings.Join(want, ",") {
+		t.Fatalf("turn 2 roles = %v, want %v", roles, want)
+	}
+	if contents[0] != "start the migration" || !strings.Contains(contents[1], `"tool_name":"search"`) ||
+		contents[2] != "Tool Result (search):\nfound 3 tables" || contents[3] != "dumped" || contents[4] != "continue" {
+		t.Fatalf("turn 2 messages = %q", contents)
+	}
+}
+
+func TestSession_HistoryBounded(t *testing.T) {
+	sess := NewSession(4)
+	for _, task := range []string{"a", "b", "c"} {
+		sess.record(task, &Final{Output: task + "!"}, NewContext(task, 1))
+	}
+	_, history := sess.snapshot()
+	if len(history) != 4 || history[0].Content != "b" || history[3].Content != "c!" {
+		t.Fatalf("history = %+v", history)
+	}
+
+	// Tool steps count towards the bound too.
+	withSteps := NewContext("d", 1)
+	withSteps.RecordStep(Step{StepNumber: 1, Action: "search", Observation: "hit"})
+	sess.record("d", &Final{Output: "d!"}, withSteps)
+	_, history = sess.snapshot()
+	if len(history) != 4 || history[0].Content != "d" || history[2].Content != "Tool Result (search):\nhit" || history[3].Content != "d!" {
+		t.Fatalf("history with steps = %+v", history)
+	}
+
+	sess.record("paused", &Final{Output: PendingOutput{Status: "pending"}}, NewContext("paused", 1))
+	if sess.Turns() != 4 {
+		t.Fatalf("pending runs must not be recorded, turns = %d", sess.Turns())
+	}
+}
+
+func TestSessionStore_BoundAndReset(t *testing.T) {
+	st := NewSessionStore(2, 0)
+	a := st.Get("a")
+	st.Get("b")
+	if st.Get("a") != a {
+		t.Fatalf("Get should return the existing session")
+	}
+	st.Get("c") // evicts "b", the least recently used
+	if st.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", st.Len())
+	}
+	if st.Get("a") != a {
+		t.Fatalf("recently used session was evicted")
+	}
+
+	a.record("x", &Final{Output: "y"}, NewContext("x", 1))
+	st.Reset("a")
+	if got := st.Get("a"); got == a || got.Turns() != 0 {
+		t.Fatalf("Reset should drop the session")
+	}
+}
@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+// validateToolParams checks params against the tool's ParameterSchema using the same
+// JSON Schema subset as RunOptions.FinalSchema. Tools whose schema is empty or cannot
+// be parsed are not validated.
+func validateToolParams(tool tools.Tool, params map[string]any) error {
+	raw := strings.TrimSpace(tool.ParameterSchema())
+	if raw == "" {
+		return nil
+	}
+	schema, err := parseFinalSchema(json.RawMessage(raw))
+	if err != nil || schema == nil {
+		return nil
+	}
+	if params == nil {
+		params = map[string]any{}
+	}
+	return schema.validate("params", params)
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/mistermorph/llm"
+)
+
+type schemaTool struct {
+	mockTool
+	schema string
+	calls  int
+}
+
+func (t *schemaTool) ParameterSchema() string { return t.schema }
+func (t *schemaTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	t.calls++
+	return t.mockTool.Execute(ctx, params)
+}
+
+const readFileSchema = `{"type":"object","properties":{"path":{"type":"string"}},"required":["path"]}`
+
+func TestValidateToolParams_MissingRequiredGetsCorrectionPrompt(t *testing.T) {
+	cases := []struct {
+		name      string
+		validate  bool
+		wantCalls int
+	}{
+		{name: "enabled", validate: true, wantCalls: 1},
+		{name: "disabled", validate: false, wantCalls: 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tool := &schemaTool{mockTool: mockTool{name: "read_file", result: "contents"}, schema: readFileSchema}
+			reg := baseRegistry()
+			reg.Register(tool)
+
+			client := newMockClient(
+				llm.Result{Text: `{"type":"tool_call","tool_call":{"thought":"t","tool_name":"read_file","tool_params":{"file":"a.txt"}}}`},
+				llm.Result{Text: `{"type":"tool_call","tool_call":{"thought":"t","tool_name":"read_file","tool_params":{"path":"a.txt"}}}`},
+				finalResponse("done"),
+			)
+			cfg := baseCfg()
+			cfg.ValidateToolParams = tc.validate
+			e := New(client, reg, cfg, DefaultPromptSpec())
+
+			_, runCtx, err := e.Run(context.Background(), "read a.txt", RunOptions{})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if tool.calls != tc.wantCalls {
+				t.Fatalf("tool executed %d times, want %d", tool.calls, tc.wantCalls)
+			}
+			if !tc.validate {
+				return
+			}
+			if len(runCtx.ToolErrors) != 1 || !strings.Contains(runCtx.ToolErrors[0].Message, `missing required property "path"`) {
+				t.Fatalf("tool errors = %+v, want the validation detail", runCtx.ToolErrors)
+			}
+			calls := client.allCalls()
+			if len(calls) < 2 {
+				t.Fatalf("expected a second LLM call, got %d", len(calls))
+			}
+			msgs := calls[1].Messages
+			last := msgs[len(msgs)-1].Content
+			if !strings.Contains(last, "invalid parameters for tool 'read_file'") || !strings.Contains(last, `missing required property "path"`) {
+				t.Fatalf("correction prompt = %q", last)
+			}
+		})
+	}
+}
+
+func TestValidateToolParams(t *testing.T) {
+	cases := []struct {
+		name    string
+		schema  string
+		params  map[string]any
+		wantErr string
+	}{
+		{name: "valid", schema: readFileSchema, params: map[string]any{"path": "a"}},
+		{name: "nil_params_missing_required", schema: readFileSchema, params: nil, wantErr: `missing required property "path"`},
+		{name: "wrong_type", schema: readFileSchema, params: map[string]any{"path": 3.0}, wantErr: "params.path: expected string"},
+		{name: "empty_schema", schema: "", params: map[string]any{"x": 1.0}},
+		{name: "unparseable_schema_skipped", schema: "not json", params: nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateToolParams(&schemaTool{schema: tc.schema}, tc.params)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
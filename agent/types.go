@@ -94,8 +94,31 @@ type Step struct {
 	Duration    time.Duration
 }
 
+// ToolError records a tool call that failed during a run (unknown tool, rejected
+// params, guard denial or an execution error). Message carries the reason, e.g. the
+// schema violation or the guard's deny reasons.
+type ToolError struct {
+	Step    int    `json:"step"`
+	Tool    string `json:"tool"`
+	Message string `json:"message"`
+}
+
 type RunOptions struct {
 	Model   string
 	History []llm.Message
 	Meta    map[string]any
+	// FinalSchema is an optional JSON Schema the final output must satisfy. On a mismatch the
+	// model is asked once to correct its answer; a second mismatch is accepted as-is and logged.
+	// Supported keywords: type, properties, required, additionalProperties (bool), items, enum.
+	FinalSchema json.RawMessage
+	// Session, when set, continues a multi-turn conversation: its history is prepended
+	// to History, its plan seeds the run, and the finished run is recorded back into it.
+	Session *Session
+	// MaxSteps and MaxTokenBudget override the engine Config for this run (e.g. a cron
+	// job that needs a bigger budget than a chat reply). Zero keeps the Config value.
+	MaxSteps       int
+	MaxTokenBudget int
+	// PlanOnly previews a run without side effects: tool calls are not executed but
+	// collected in Context.IntendedToolCalls, and the model is told they did not run.
+	PlanOnly bool
 }
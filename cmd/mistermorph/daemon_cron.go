@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/agent"
+	"github.com/quailyquaily/mistermorph/db/models"
+	"github.com/quailyquaily/mistermorph/llm"
+	"github.com/quailyquaily/mistermorph/scheduler"
+	"github.com/spf13/viper"
+)
+
+// cronRunOptions builds the agent options for a scheduled run, applying the
+// scheduler.max_steps / scheduler.max_token_budget overrides.
+func cronRunOptions(model string, meta map[string]any) agent.RunOptions {
+	return agent.RunOptions{
+		Model:          model,
+		Meta:           meta,
+		MaxSteps:       viper.GetInt("scheduler.max_steps"),
+		MaxTokenBudget: viper.GetInt("scheduler.max_token_budget"),
+	}
+}
+
+// cronProgressHook reports each agent step as interim progress of the scheduled run
+// the context belongs to; outside a cron run scheduler.ReportProgress is a no-op.
+func cronProgressHook(ctx context.Context, step int, agentCtx *agent.Context, _ *[]llm.Message) error {
+	if step == 0 || agentCtx == nil {
+		return nil
+	}
+	msg := fmt.Sprintf("in progress: %d of up to %d steps done", step, agentCtx.MaxSteps)
+	if n := len(agentCtx.Steps); n > 0 && strings.TrimSpace(agentCtx.Steps[n-1].Action) != "" {
+		msg += ", last action: " + agentCtx.Steps[n-1].Action
+	}
+	scheduler.ReportProgress(ctx, msg)
+	return nil
+}
+
+// mirrorCronRunsToStore hooks the scheduler callbacks so that every cron run is
+// also visible in the daemon task store (GET /tasks, labels source=cron).
+// An existing OnRunFinished callback is kept and still invoked.
+func mirrorCronRunsToStore(cfg *scheduler.Config, store *TaskStore, defaultModel string) {
+	if cfg == nil || store == nil {
+		return
+	}
+	cfg.OnRunStarted = func(ctx context.Context, job models.CronJob, run models.CronRun) error {
+		store.Upsert(cronTaskInfo(job, run, defaultModel, scheduler.StatusRunning, nil, nil))
+		return nil
+	}
+	prev := cfg.OnRunFinished
+	cfg.OnRunFinished = func(ctx context.Context, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) error {
+		store.Upsert(cronTaskInfo(job, run, defaultModel, status, errStr, summary))
+		if prev != nil {
+			return prev(ctx, job, run, status, errStr, summary)
+		}
+		return nil
+	}
+}
+
+func cronTaskID(runID string) string {
+	return "cron_" + runID
+}
+
+func cronTaskInfo(job models.CronJob, run models.CronRun, defaultModel string, status string, errStr *string, summary *string) TaskInfo {
+	model := defaultModel
+	if job.Model != nil && strings.TrimSpace(*job.Model) != "" {
+		model = strings.TrimSpace(*job.Model)
+	}
+	info := TaskInfo{
+		ID:     cronTaskID(run.ID),
+		Status: cronTaskStatus(status),
+		Task:   job.Task,
+		Model:  model,
+		Labels: map[string]string{
+			"source":      "cron",
+			"cron_job_id": job.ID,
+			"cron_run_id": run.ID,
+			"cron_job":    job.Name,
+		},
+		CreatedAt: time.Unix(run.ScheduledFor, 0),
+	}
+	if job.TimeoutSeconds != nil && *job.TimeoutSeconds > 0 {
+		info.Timeout = (time.Duration(*job.TimeoutSeconds) * time.Second).String()
+	}
+	if run.StartedAt != nil {
+		started := time.Unix(*run.StartedAt, 0)
+		info.StartedAt = &started
+	}
+	if isTerminal(info.Status) {
+		finished := time.Now()
+		info.FinishedAt = &finished
+	}
+	if errStr != nil {
+		info.Error = *errStr
+	}
+	if summary != nil {
+		info.Result = map[string]any{"summary": *summary}
+	}
+	return info
+}
+
+// cronTaskStatus maps cron_runs statuses onto daemon task statuses.
+func cronTaskStatus(status string) TaskStatus {
+	switch status {
+	case scheduler.StatusQueued:
+		return TaskQueued
+	case scheduler.StatusRunning:
+		return TaskRunning
+	case scheduler.StatusSuccess, scheduler.StatusSkipped:
+		return TaskDone
+	case scheduler.StatusCanceled:
+		return TaskCanceled
+	default:
+		return TaskFailed
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quailyquaily/mistermorph/agent"
+	"github.com/quailyquaily/mistermorph/db/models"
+	"github.com/quailyquaily/mistermorph/scheduler"
+)
+
+func TestMirrorCronRunsToStore_StatusTransitions(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    string
+		errStr    string
+		summary   string
+		want      TaskStatus
+		wantError string
+	}{
+		{name: "succeeded", status: scheduler.StatusSuccess, summary: "all good", want: TaskDone},
+		{name: "failed", status: scheduler.StatusFailed, errStr: "boom", want: TaskFailed, wantError: "boom"},
+		{name: "timed_out", status: scheduler.StatusTimedOut, errStr: "timeout", want: TaskFailed, wantError: "timeout"},
+		{name: "canceled", status: scheduler.StatusCanceled, errStr: "canceled", want: TaskCanceled, wantError: "canceled"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewTaskStore(10)
+			defer store.Close()
+
+			var prevCalled bool
+			cfg := scheduler.DefaultConfig()
+			cfg.OnRunFinished = func(ctx context.Context, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) error {
+				prevCalled = true
+				return nil
+			}
+			mirrorCronRunsToStore(&cfg, store, "default-model")
+
+			started := int64(1700000000)
+			job := models.CronJob{ID: "job1", Name: "daily", Task: "summarize inbox"}
+			run := models.CronRun{ID: "run1", JobID: "job1", Status: scheduler.StatusRunning, ScheduledFor: started, StartedAt: &started}
+			cronLabels := map[string]string{"source": "cron"}
+
+			if err := cfg.OnRunStarted(context.Background(), job, run); err != nil {
+				t.Fatalf("OnRunStarted: %v", err)
+			}
+			got := store.List(TaskListFilter{Labels: cronLabels})
+			if len(got) != 1 || got[0].ID != cronTaskID("run1") || got[0].Status != TaskRunning {
+				t.Fatalf("after start: %+v", got)
+			}
+			if got[0].Task != "summarize inbox" || got[0].Model != "default-model" || got[0].Labels["cron_job_id"] != "job1" {
+				t.Fatalf("after start: %+v", got[0])
+			}
+			if got[0].StartedAt == nil || got[0].StartedAt.Unix() != started || got[0].FinishedAt != nil {
+				t.Fatalf("after start timestamps: %+v", got[0])
+			}
+
+			var errStr, summary *string
+			if tc.errStr != "" {
+				errStr = &tc.errStr
+			}
+			if tc.summary != "" {
+				summary = &tc.summary
+			}
+			if err := cfg.OnRunFinished(context.Background(), job, run, tc.status, errStr, summary); err != nil {
+				t.Fatalf("OnRunFinished: %v", err)
+			}
+			if !prevCalled {
+				t.Fatalf("existing OnRunFinished callback was not invoked")
+			}
+			got = store.List(TaskListFilter{Labels: cronLabels})
+			if len(got) != 1 || got[0].Status != tc.want || got[0].Error != tc.wantError || got[0].FinishedAt == nil {
+				t.Fatalf("after finish: %+v", got)
+			}
+			if tc.summary != "" {
+				res, _ := got[0].Result.(map[string]any)
+				if res["summary"] != tc.summary {
+					t.Fatalf("result = %#v", got[0].Result)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskStore_UpsertDoesNotQueue(t *testing.T) {
+	store := NewTaskStore(1)
+	defer store.Close()
+
+	store.Upsert(TaskInfo{ID: "ext", Status: TaskRunning})
+	if _, err := store.Enqueue(context.Background(), "queued", "m", 0); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	qt, ok := store.Next()
+	if !ok || qt.info.Task != "queued" {
+		t.Fatalf("Next returned %+v, want the queued task", qt)
+	}
+	if info, ok := store.Get("ext"); !ok || info.Status != TaskRunning {
+		t.Fatalf("Get(ext) = %+v, %v", info, ok)
+	}
+}
+
+func TestCronProgressHook(t *testing.T) {
+	var got []string
+	ctx := scheduler.WithProgress(context.Background(), func(s string) { got = append(got, s) })
+	agentCtx := agent.NewContext("task", 10)
+
+	if err := cronProgressHook(ctx, 0, agentCtx, nil); err != nil {
+		t.Fatal(err)
+	}
+	agentCtx.Steps = append(agentCtx.Steps, agent.Step{StepNumber: 1, Action: "web_search"})
+	if err := cronProgressHook(ctx, 1, agentCtx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "in progress: 1 of up to 10 steps done, last action: web_search" {
+		t.Fatalf("progress = %q", got)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Stable machine-readable error codes returned by the daemon HTTP API.
+// Clients should branch on these rather than on message text.
+const (
+	errCodeMethodNotAllowed = "method_not_allowed"
+	errCodeUnauthorized     = "unauthorized"
+	errCodeInvalidJSON      = "invalid_json"
+	errCodeInvalidRequest   = "invalid_request"
+	errCodeNotFound         = "not_found"
+	errCodeQueueUnavailable = "queue_unavailable"
+	errCodeGuardDisabled    = "guard_disabled"
+	errCodeConflict         = "conflict"
+	errCodeInternal         = "internal_error"
+)
+
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeError writes an ErrorResponse body with the given HTTP status.
+func writeError(w http.ResponseWriter, status int, code string, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Code: code, Message: msg})
+}
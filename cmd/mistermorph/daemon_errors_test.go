@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeErrorResponse(t *testing.T, rec *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("content-type = %q, want application/json", ct)
+	}
+	var out ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return out
+}
+
+func TestWriteSubmitError_Codes(t *testing.T) {
+	open := NewTaskStore(10)
+	defer open.Close()
+	closed := NewTaskStore(10)
+	closed.Close()
+
+	cases := []struct {
+		name       string
+		store      *TaskStore
+		req        SubmitTaskRequest
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "missing_task", store: open, req: SubmitTaskRequest{}, wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidRequest},
+		{name: "bad_timeout", store: open, req: SubmitTaskRequest{Task: "x", Timeout: "soon"}, wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidRequest},
+		{name: "store_closed", store: closed, req: SubmitTaskRequest{Task: "x"}, wantStatus: http.StatusServiceUnavailable, wantCode: errCodeQueueUnavailable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := submitTask(tc.store, tc.req, time.Minute, "m")
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			rec := httptest.NewRecorder()
+			writeSubmitError(rec, err)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			got := decodeErrorResponse(t, rec)
+			if got.Code != tc.wantCode || got.Message != err.Error() {
+				t.Fatalf("body = %+v, want code %q message %q", got, tc.wantCode, err.Error())
+			}
+		})
+	}
+}
+
+func TestSubmitBatch_JSONErrors(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+	h := newTestBatchHandler(store)
+
+	cases := []struct {
+		name       string
+		auth       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "unauthorized", auth: "Bearer wrong", body: `[]`, wantStatus: http.StatusUnauthorized, wantCode: errCodeUnauthorized},
+		{name: "invalid_json", auth: "Bearer secret", body: `{`, wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidJSON},
+		{name: "empty", auth: "Bearer secret", body: `[]`, wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks/batch", strings.NewReader(tc.body))
+			req.Header.Set("Authorization", tc.auth)
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if got := decodeErrorResponse(t, rec); got.Code != tc.wantCode || got.Message == "" {
+				t.Fatalf("body = %+v, want code %q", got, tc.wantCode)
+			}
+		})
+	}
+}
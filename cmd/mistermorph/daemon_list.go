@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultTaskListLimit = 50
+	maxTaskListLimit     = 500
+)
+
+// parseTaskListFilter reads GET /tasks query params:
+//   - status=<queued|running|pending|done|failed|canceled>
+//   - label=key:value (repeatable; all must match)
+//   - limit=<n> (default 50, max 500)
+func parseTaskListFilter(q url.Values) (TaskListFilter, error) {
+	filter := TaskListFilter{Limit: defaultTaskListLimit}
+
+	if raw := strings.TrimSpace(q.Get("status")); raw != "" {
+		st := TaskStatus(strings.ToLower(raw))
+		switch st {
+		case TaskQueued, TaskRunning, TaskPending, TaskDone, TaskFailed, TaskCanceled:
+			filter.Status = st
+		default:
+			return filter, fmt.Errorf("invalid status %q", raw)
+		}
+	}
+
+	for _, raw := range q["label"] {
+		k, v, ok := strings.Cut(raw, ":")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return filter, fmt.Errorf("invalid label filter %q (use key:value)", raw)
+		}
+		if filter.Labels == nil {
+			filter.Labels = make(map[string]string)
+		}
+		filter.Labels[k] = strings.TrimSpace(v)
+	}
+
+	if raw := strings.TrimSpace(q.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return filter, fmt.Errorf("invalid limit %q", raw)
+		}
+		if n > maxTaskListLimit {
+			n = maxTaskListLimit
+		}
+		filter.Limit = n
+	}
+	return filter, nil
+}
+
+func handleListTasks(w http.ResponseWriter, r *http.Request, store *TaskStore) {
+	filter, err := parseTaskListFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	tasks := store.List(filter)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(TaskListResponse{Count: len(tasks), Tasks: tasks})
+}
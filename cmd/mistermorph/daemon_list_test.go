@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTaskStore_ListFiltersByLabelAndStatus(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+
+	submit := func(task string, labels map[string]string) *TaskInfo {
+		t.Helper()
+		info, _, err := submitTask(store, SubmitTaskRequest{Task: task, Labels: labels}, time.Minute, "m")
+		if err != nil {
+			t.Fatalf("submit %q: %v", task, err)
+		}
+		return info
+	}
+	cron := submit("a", map[string]string{" source ": " cron ", "team": "ops"})
+	manual := submit("b", map[string]string{"source": "manual"})
+	submit("c", nil)
+	cron2 := submit("d", map[string]string{"source": "cron"})
+	store.Update(cron2.ID, func(info *TaskInfo) { info.Status = TaskDone })
+
+	if got, _ := store.Get(cron.ID); got.Labels["source"] != "cron" {
+		t.Fatalf("labels not normalized: %#v", got.Labels)
+	}
+
+	cases := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "all", query: "", want: nil},
+		{name: "label", query: "label=source:cron", want: []string{cron2.ID, cron.ID}},
+		{name: "label_and_label", query: "label=source:cron&label=team:ops", want: []string{cron.ID}},
+		{name: "label_and_status", query: "label=source:cron&status=done", want: []string{cron2.ID}},
+		{name: "status_only", query: "status=queued&label=source:manual", want: []string{manual.ID}},
+		{name: "limit", query: "label=source:cron&limit=1", want: []string{cron2.ID}},
+		{name: "no_match", query: "label=source:slack", want: []string{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tc.query)
+			filter, err := parseTaskListFilter(q)
+			if err != nil {
+				t.Fatalf("parseTaskListFilter: %v", err)
+			}
+			got := store.List(filter)
+			if tc.want == nil {
+				if len(got) != 4 {
+					t.Fatalf("len = %d, want 4", len(got))
+				}
+				return
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("len = %d, want %d (%+v)", len(got), len(tc.want), got)
+			}
+			for i := range tc.want {
+				if got[i].ID != tc.want[i] {
+					t.Fatalf("got[%d] = %s, want %s", i, got[i].ID, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseTaskListFilter_Invalid(t *testing.T) {
+	for _, raw := range []string{"label=nocolon", "label=:v", "status=bogus", "limit=0", "limit=x"} {
+		q, _ := url.ParseQuery(raw)
+		if _, err := parseTaskListFilter(q); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestSubmitTask_RejectsInvalidLabels(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+
+	_, _, err := submitTask(store, SubmitTaskRequest{Task: "x", Labels: map[string]string{" ": "v"}}, time.Minute, "m")
+	if err == nil || submitErrorStatus(err) != http.StatusBadRequest {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+}
+
+func TestHandleListTasks_EncodesResponse(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+	if _, _, err := submitTask(store, SubmitTaskRequest{Task: "x", Labels: map[string]string{"source": "cron"}}, time.Minute, "m"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handleListTasks(rec, httptest.NewRequest(http.MethodGet, "/tasks?label=source:cron", nil), store)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var resp TaskListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Count != 1 || len(resp.Tasks) != 1 || resp.Tasks[0].Labels["source"] != "cron" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+)
+
+// writeMetrics renders daemon internals in the Prometheus text exposition format.
+// Cron runs are mirrored into the task store (labels source=cron), so scheduler
+// counts come from the same place as task counts.
+func writeMetrics(w io.Writer, store *TaskStore) {
+	writeStatusGauge := func(name string, help string, st TaskStats) {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		for _, kv := range []struct {
+			status string
+			n      int
+		}{
+			{"queued", st.Queued},
+			{"running", st.Running},
+			{"pending", st.Pending},
+			{"succeeded", st.Succeeded},
+			{"failed", st.Failed},
+			{"canceled", st.Canceled},
+		} {
+			fmt.Fprintf(w, "%s{status=%q} %d\n", name, kv.status, kv.n)
+		}
+	}
+
+	all := store.Stats()
+	writeStatusGauge("mistermorph_tasks", "Tasks retained by the daemon task store, by status.", all)
+	writeStatusGauge("mistermorph_cron_runs", "Scheduler runs retained by the daemon task store, by status.", store.StatsForLabels(map[string]string{"source": "cron"}))
+
+	fmt.Fprintf(w, "# HELP mistermorph_task_store_size Tasks currently retained by the task store.\n")
+	fmt.Fprintf(w, "# TYPE mistermorph_task_store_size gauge\n")
+	fmt.Fprintf(w, "mistermorph_task_store_size %d\n", store.Len())
+
+	fmt.Fprintf(w, "# HELP mistermorph_task_oldest_queued_age_seconds Age of the oldest queued task (0 when none).\n")
+	fmt.Fprintf(w, "# TYPE mistermorph_task_oldest_queued_age_seconds gauge\n")
+	fmt.Fprintf(w, "mistermorph_task_oldest_queued_age_seconds %g\n", all.OldestQueuedAgeSeconds)
+
+	fmt.Fprintf(w, "# HELP go_goroutines Number of goroutines that currently exist.\n")
+	fmt.Fprintf(w, "# TYPE go_goroutines gauge\n")
+	fmt.Fprintf(w, "go_goroutines %d\n", runtime.NumGoroutine())
+
+	fmt.Fprintf(w, "# HELP mistermorph_build_info Build information.\n")
+	fmt.Fprintf(w, "# TYPE mistermorph_build_info gauge\n")
+	fmt.Fprintf(w, "mistermorph_build_info{version=%q} 1\n", buildVersion())
+}
+
+func newMetricsHandler(store *TaskStore, auth string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !checkAuth(r, auth) {
+			writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "unauthorized")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeMetrics(w, store)
+	}
+}
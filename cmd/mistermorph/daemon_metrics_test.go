@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+	store.Upsert(TaskInfo{ID: "t1", Status: TaskDone})
+	store.Upsert(TaskInfo{ID: "t2", Status: TaskFailed})
+	store.Upsert(TaskInfo{ID: "cron_r1", Status: TaskDone, Labels: map[string]string{"source": "cron"}})
+
+	h := newMetricsHandler(store, "secret")
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("no auth: status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h(rec, req)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("status = %d content-type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	// Parse the exposition: every sample line is "<name>[{labels}] <float>".
+	samples := map[string]float64{}
+	for _, line := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			if !strings.HasPrefix(line, "# HELP ") && !strings.HasPrefix(line, "# TYPE ") {
+				t.Fatalf("bad comment line %q", line)
+			}
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		if i <= 0 {
+			t.Fatalf("bad sample line %q", line)
+		}
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("bad value in %q: %v", line, err)
+		}
+		samples[line[:i]] = v
+	}
+
+	want := map[string]float64{
+		`mistermorph_tasks{status="succeeded"}`:      2,
+		`mistermorph_tasks{status="failed"}`:         1,
+		`mistermorph_tasks{status="queued"}`:         0,
+		`mistermorph_cron_runs{status="succeeded"}`:  1,
+		`mistermorph_cron_runs{status="failed"}`:     0,
+		`mistermorph_task_store_size`:                3,
+		`mistermorph_task_oldest_queued_age_seconds`: 0,
+	}
+	for name, v := range want {
+		got, ok := samples[name]
+		if !ok || got != v {
+			t.Fatalf("%s = %v (present=%v), want %v", name, got, ok, v)
+		}
+	}
+	if samples["go_goroutines"] < 1 {
+		t.Fatalf("go_goroutines = %v", samples["go_goroutines"])
+	}
+	if samples[`mistermorph_build_info{version="`+buildVersion()+`"}`] != 1 {
+		t.Fatalf("missing build info in %v", samples)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/skills"
+)
+
+// defaultDependencyCheckTimeout bounds each dependency probe so /overview stays responsive.
+const defaultDependencyCheckTimeout = 2 * time.Second
+
+// dependencyCheck is a cheap reachability probe. A nil error means reachable.
+type dependencyCheck func(ctx context.Context) error
+
+type DependencyStatus struct {
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type OverviewResponse struct {
+	Mode         string                      `json:"mode"`
+	Version      string                      `json:"version"`
+	Time         string                      `json:"time"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+	Skills       map[string]skills.UsageStat `json:"skills"`
+}
+
+// runDependencyChecks runs every check concurrently, each under its own timeout.
+// Failures are reported per dependency and never fail the overview itself.
+func runDependencyChecks(ctx context.Context, checks map[string]dependencyCheck, timeout time.Duration) map[string]DependencyStatus {
+	if timeout <= 0 {
+		timeout = defaultDependencyCheckTimeout
+	}
+	type result struct {
+		name   string
+		status DependencyStatus
+	}
+	ch := make(chan result, len(checks))
+	for name, check := range checks {
+		go func(name string, check dependencyCheck) {
+			cctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			errCh := make(chan error, 1)
+			go func() { errCh <- check(cctx) }()
+			var err error
+			select {
+			case err = <-errCh:
+			case <-cctx.Done():
+				err = cctx.Err()
+			}
+			st := DependencyStatus{OK: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				st.Error = err.Error()
+			}
+			ch <- result{name: name, status: st}
+		}(name, check)
+	}
+	out := make(map[string]DependencyStatus, len(checks))
+	for range checks {
+		r := <-ch
+		out[r.name] = r.status
+	}
+	return out
+}
+
+// llmEndpointCheck treats any HTTP response from the provider endpoint as reachable;
+// it does not spend tokens or validate credentials.
+func llmEndpointCheck(endpoint string) dependencyCheck {
+	endpoint = strings.TrimSpace(endpoint)
+	return func(ctx context.Context) error {
+		if endpoint == "" {
+			return fmt.Errorf("llm.endpoint is not configured")
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		return nil
+	}
+}
+
+// handleHealth serves the unauthenticated liveness probe. It includes the build version
+// so probes can spot version skew without an authenticated /overview call.
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":      true,
+		"time":    time.Now().Format(time.RFC3339Nano),
+		"version": buildVersion(),
+	})
+}
+
+func newOverviewHandler(auth string, mode string, checks map[string]dependencyCheck, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !checkAuth(r, auth) {
+			writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "unauthorized")
+			return
+		}
+		out := OverviewResponse{
+			Mode:         mode,
+			Version:      buildVersion(),
+			Time:         time.Now().Format(time.RFC3339Nano),
+			Dependencies: runDependencyChecks(r.Context(), checks, timeout),
+			Skills:       skillUsage.Snapshot(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOverview_ReportsFailingDependency(t *testing.T) {
+	checks := map[string]dependencyCheck{
+		"db":  func(ctx context.Context) error { return errors.New("database is locked") },
+		"llm": func(ctx context.Context) error { return nil },
+	}
+	h := newOverviewHandler("secret", "serve", checks, time.Second)
+
+	req := httptest.NewRequest(http.MethodGet, "/overview", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (deps must be non-fatal)", rec.Code)
+	}
+	var resp OverviewResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Mode != "serve" {
+		t.Fatalf("mode = %q", resp.Mode)
+	}
+	dbStatus, ok := resp.Dependencies["db"]
+	if !ok {
+		t.Fatalf("missing db dependency: %+v", resp.Dependencies)
+	}
+	if dbStatus.OK || dbStatus.Error != "database is locked" {
+		t.Fatalf("db = %+v, want failing with error", dbStatus)
+	}
+	if llmStatus := resp.Dependencies["llm"]; !llmStatus.OK || llmStatus.Error != "" {
+		t.Fatalf("llm = %+v, want ok", llmStatus)
+	}
+}
+
+func TestRunDependencyChecks_TimesOut(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	checks := map[string]dependencyCheck{
+		"slow": func(ctx context.Context) error { <-block; return nil },
+	}
+
+	start := time.Now()
+	got := runDependencyChecks(context.Background(), checks, 50*time.Millisecond)
+	if time.Since(start) > time.Second {
+		t.Fatalf("checks were not time-boxed")
+	}
+	if st := got["slow"]; st.OK || st.Error == "" {
+		t.Fatalf("slow = %+v, want timeout error", st)
+	}
+}
+
+func TestLLMEndpointCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if err := llmEndpointCheck(srv.URL)(context.Background()); err != nil {
+		t.Fatalf("expected reachable endpoint, got %v", err)
+	}
+	if err := llmEndpointCheck("")(context.Background()); err == nil {
+		t.Fatalf("expected error for empty endpoint")
+	}
+}
+
+func TestHealth_IncludesVersion(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleHealth(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if v, _ := body["version"].(string); v == "" {
+		t.Fatalf("missing version in health payload: %v", body)
+	}
+	if body["ok"] != true {
+		t.Fatalf("ok = %v", body["ok"])
+	}
+
+	rec = httptest.NewRecorder()
+	handleHealth(rec, httptest.NewRequest(http.MethodHead, "/health", nil))
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Fatalf("HEAD: status=%d body=%q", rec.Code, rec.Body.String())
+	}
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"math/rand/v2"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -11,6 +14,9 @@ import (
 
 const defaultCompletedTTL = 30 * time.Minute
 
+// errIdempotencyConflict is returned when an idempotency key is reused for a different submission.
+var errIdempotencyConflict = errors.New("idempotency key was already used for a different task")
+
 type queuedTask struct {
 	info   *TaskInfo
 	ctx    context.Context
@@ -18,6 +24,8 @@ type queuedTask struct {
 
 	// resumeApprovalID is set when re-queued to resume a paused run from an approval request.
 	resumeApprovalID string
+	// idempotencyKey is the client-supplied submit key, if any; released on eviction.
+	idempotencyKey string
 }
 
 type TaskStore struct {
@@ -27,6 +35,8 @@ type TaskStore struct {
 	done         chan struct{} // closed by Close() to signal shutdown
 	closeOnce    sync.Once
 	completedTTL time.Duration
+	// idempotency maps client-supplied submit keys to task ids for as long as the task is retained.
+	idempotency map[string]string
 }
 
 func NewTaskStore(maxQueue int) *TaskStore {
@@ -38,12 +48,27 @@ func NewTaskStore(maxQueue int) *TaskStore {
 		queue:        make(chan *queuedTask, maxQueue),
 		done:         make(chan struct{}),
 		completedTTL: defaultCompletedTTL,
+		idempotency:  make(map[string]string),
 	}
 	go s.evictLoop()
 	return s
 }
 
 func (s *TaskStore) Enqueue(parent context.Context, task string, model string, timeout time.Duration) (*TaskInfo, error) {
+	return s.EnqueueLabeled(parent, task, model, timeout, nil)
+}
+
+// EnqueueLabeled is like Enqueue but attaches labels to the task for later filtering.
+func (s *TaskStore) EnqueueLabeled(parent context.Context, task string, model string, timeout time.Duration, labels map[string]string) (*TaskInfo, error) {
+	info, _, err := s.EnqueueIdempotent(parent, "", task, model, timeout, labels)
+	return info, err
+}
+
+// EnqueueIdempotent is like EnqueueLabeled, but when key is non-empty and a retained task
+// was already submitted with the same key, it returns a copy of that task and existed=true
+// instead of enqueuing a new one. Reusing a key for a different task, model, timeout or
+// labels fails with errIdempotencyConflict.
+func (s *TaskStore) EnqueueIdempotent(parent context.Context, key string, task string, model string, timeout time.Duration, labels map[string]string) (info *TaskInfo, existed bool, err error) {
 	if timeout <= 0 {
 		timeout = 10 * time.Minute
 	}
@@ -53,40 +78,90 @@ func (s *TaskStore) Enqueue(parent context.Context, task string, model string, t
 
 	select {
 	case <-s.done:
-		return nil, fmt.Errorf("store is closed")
+		return nil, false, fmt.Errorf("store is closed")
 	default:
 	}
 
 	id := fmt.Sprintf("%x", rand.Uint64())
 	now := time.Now()
-	ctx, cancel := context.WithTimeout(parent, timeout)
 
-	info := &TaskInfo{
+	s.mu.Lock()
+	if key != "" {
+		if prevID, ok := s.idempotency[key]; ok {
+			if prev := s.tasks[prevID]; prev != nil && prev.info != nil {
+				if !sameSubmission(prev.info, task, model, timeout, labels) {
+					s.mu.Unlock()
+					return nil, false, errIdempotencyConflict
+				}
+				cp := *prev.info
+				cp.Labels = copyLabels(prev.info.Labels)
+				s.mu.Unlock()
+				return &cp, true, nil
+			}
+			delete(s.idempotency, key)
+		}
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	info = &TaskInfo{
 		ID:        id,
 		Status:    TaskQueued,
 		Task:      task,
 		Model:     model,
 		Timeout:   timeout.String(),
+		Labels:    copyLabels(labels),
 		CreatedAt: now,
 	}
-	qt := &queuedTask{info: info, ctx: ctx, cancel: cancel}
-
-	s.mu.Lock()
+	qt := &queuedTask{info: info, ctx: ctx, cancel: cancel, idempotencyKey: key}
 	s.tasks[id] = qt
+	if key != "" {
+		s.idempotency[key] = id
+	}
 	s.mu.Unlock()
 
 	select {
 	case s.queue <- qt:
-		return info, nil
+		return info, false, nil
 	default:
 		qt.cancel()
 		s.mu.Lock()
-		delete(s.tasks, id)
+		s.removeLocked(id)
 		s.mu.Unlock()
-		return nil, fmt.Errorf("queue is full")
+		return nil, false, fmt.Errorf("queue is full")
 	}
 }
 
+// Upsert inserts or replaces a task record that is executed outside the queue
+// (e.g. a scheduler run), so it shows up in Get/List alongside queued tasks.
+// Records inserted this way are evicted like any other finished task.
+func (s *TaskStore) Upsert(info TaskInfo) {
+	if strings.TrimSpace(info.ID) == "" {
+		return
+	}
+	info.Labels = copyLabels(info.Labels)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if qt := s.tasks[info.ID]; qt != nil && qt.info != nil {
+		*qt.info = info
+		return
+	}
+	s.tasks[info.ID] = &queuedTask{info: &info}
+}
+
+// removeLocked drops a task and releases its idempotency key. Caller holds s.mu.
+func (s *TaskStore) removeLocked(id string) {
+	if qt := s.tasks[id]; qt != nil && qt.idempotencyKey != "" && s.idempotency[qt.idempotencyKey] == id {
+		delete(s.idempotency, qt.idempotencyKey)
+	}
+	delete(s.tasks, id)
+}
+
+// Len returns the number of tasks currently retained, in any status.
+func (s *TaskStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tasks)
+}
+
 func (s *TaskStore) Get(id string) (*TaskInfo, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -96,9 +171,76 @@ func (s *TaskStore) Get(id string) (*TaskInfo, bool) {
 	}
 	// Return a shallow copy for safe reads.
 	cp := *qt.info
+	cp.Labels = copyLabels(qt.info.Labels)
 	return &cp, true
 }
 
+// TaskListFilter narrows List results. Zero values match everything.
+type TaskListFilter struct {
+	Status TaskStatus
+	// Labels must all match (AND).
+	Labels map[string]string
+	Limit  int
+}
+
+// List returns copies of stored tasks matching filter, newest first.
+func (s *TaskStore) List(filter TaskListFilter) []TaskInfo {
+	s.mu.RLock()
+	out := make([]TaskInfo, 0, len(s.tasks))
+	for _, qt := range s.tasks {
+		if qt == nil || qt.info == nil {
+			continue
+		}
+		if filter.Status != "" && qt.info.Status != filter.Status {
+			continue
+		}
+		if !labelsMatch(qt.info.Labels, filter.Labels) {
+			continue
+		}
+		cp := *qt.info
+		cp.Labels = copyLabels(qt.info.Labels)
+		out = append(out, cp)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(out) > filter.Limit {
+		out = out[:filter.Limit]
+	}
+	return out
+}
+
+func labelsMatch(have, want map[string]string) bool {
+	for k, v := range want {
+		got, ok := have[k]
+		if !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// sameSubmission reports whether a retained task matches a resubmission under the same key.
+func sameSubmission(info *TaskInfo, task string, model string, timeout time.Duration, labels map[string]string) bool {
+	return info.Task == task && info.Model == model && info.Timeout == timeout.String() && maps.Equal(info.Labels, labels)
+}
+
+func copyLabels(in map[string]string) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
 // Next blocks until a task is available or the store is closed.
 // Returns (nil, false) when the store is closed.
 func (s *TaskStore) Next() (*queuedTask, bool) {
@@ -129,6 +271,18 @@ func (s *TaskStore) Update(id string, fn func(info *TaskInfo)) {
 	fn(qt.info)
 }
 
+// RecordRetry counts one more LLM retry for task id and remembers the error that
+// caused it. It is meant to be wired up via llm.WithRetryObserver.
+func (s *TaskStore) RecordRetry(id string, err error) {
+	if err == nil {
+		return
+	}
+	s.Update(id, func(info *TaskInfo) {
+		info.LLMRetries++
+		info.LastError = err.Error()
+	})
+}
+
 func (s *TaskStore) EnqueueResumeByApprovalID(approvalRequestID string) (string, error) {
 	approvalRequestID = strings.TrimSpace(approvalRequestID)
 	if approvalRequestID == "" {
@@ -257,7 +411,7 @@ func (s *TaskStore) evictExpired() {
 			continue
 		}
 		if qt.info.FinishedAt != nil && now.Sub(*qt.info.FinishedAt) > ttl {
-			delete(s.tasks, id)
+			s.removeLocked(id)
 		}
 	}
 }
@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/quailyquaily/mistermorph/llm"
 )
 
 func TestTaskStore_NextReturnsOnClose(t *testing.T) {
@@ -147,3 +150,32 @@ func TestTaskStore_EvictKeepsRunningTasks(t *testing.T) {
 		t.Fatal("running task was incorrectly evicted")
 	}
 }
+
+func TestTaskStore_RecordRetry(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+
+	info, err := store.Enqueue(context.Background(), "t", "m", time.Minute)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if got, _ := store.Get(info.ID); got.LLMRetries != 0 || got.LastError != "" {
+		t.Fatalf("fresh task: llm_retries=%d last_error=%q", got.LLMRetries, got.LastError)
+	}
+
+	// Simulate a run whose LLM calls were retried twice before succeeding.
+	ctx := llm.WithRetryObserver(context.Background(), func(err error) { store.RecordRetry(info.ID, err) })
+	llm.ReportRetry(ctx, errors.New("openai http 429: rate limited"))
+	llm.ReportRetry(ctx, errors.New("openai http 503: unavailable"))
+
+	got, _ := store.Get(info.ID)
+	if got.LLMRetries != 2 || got.LastError != "openai http 503: unavailable" {
+		t.Fatalf("after retries: llm_retries=%d last_error=%q", got.LLMRetries, got.LastError)
+	}
+
+	// Records from sources that never retry report none.
+	store.Upsert(TaskInfo{ID: "ext", Status: TaskDone})
+	if ext, _ := store.Get("ext"); ext.LLMRetries != 0 {
+		t.Fatalf("upserted llm_retries = %d, want 0", ext.LLMRetries)
+	}
+}
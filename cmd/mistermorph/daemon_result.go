@@ -1,6 +1,10 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/quailyquaily/mistermorph/agent"
 )
 
@@ -22,3 +26,52 @@ func summarizeSteps(ctx *agent.Context) []map[string]any {
 	}
 	return out
 }
+
+// collectArtifacts lists files the run wrote with write_file that still exist under
+// baseDir (file_cache_dir). Paths are reported relative to baseDir; anything that
+// resolves outside it is ignored. Later writes to the same file replace earlier ones.
+func collectArtifacts(ctx *agent.Context, baseDir string) []ArtifactRef {
+	if ctx == nil || len(ctx.Steps) == 0 {
+		return nil
+	}
+	baseDir = expandHome(baseDir)
+	if baseDir == "" {
+		return nil
+	}
+	baseAbs, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil
+	}
+
+	var out []ArtifactRef
+	index := make(map[string]int)
+	for _, s := range ctx.Steps {
+		if s.Action != "write_file" || s.Error != nil {
+			continue
+		}
+		p, _ := s.ActionInput["path"].(string)
+		p = expandHome(p)
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(baseAbs, p)
+		}
+		rel, err := filepath.Rel(baseAbs, filepath.Clean(p))
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		fi, err := os.Stat(filepath.Join(baseAbs, rel))
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		ref := ArtifactRef{Path: filepath.ToSlash(rel), Filename: fi.Name(), Size: fi.Size()}
+		if i, ok := index[ref.Path]; ok {
+			out[i] = ref
+			continue
+		}
+		index[ref.Path] = len(out)
+		out = append(out, ref)
+	}
+	return out
+}
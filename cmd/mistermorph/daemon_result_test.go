@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/mistermorph/agent"
+)
+
+func TestCollectArtifacts(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "reports"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "reports", "weekly.md"), []byte("# weekly"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "notes.txt"), []byte("hello world"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	if err := os.WriteFile(outside, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(path string) agent.Step {
+		return agent.Step{Action: "write_file", ActionInput: map[string]any{"path": path}}
+	}
+	failed := write("notes.txt")
+	failed.Error = errors.New("disk full")
+	ctx := &agent.Context{Steps: []agent.Step{
+		write("reports/weekly.md"),
+		{Action: "read_file", ActionInput: map[string]any{"path": "notes.txt"}},
+		failed,
+		write(filepath.Join(base, "notes.txt")),
+		write("notes.txt"), // duplicate: reported once
+		write("../escape.txt"),
+		write(outside),
+		write("missing.txt"),
+		write("reports"), // directory
+	}}
+
+	got := collectArtifacts(ctx, base)
+	want := []ArtifactRef{
+		{Path: "reports/weekly.md", Filename: "weekly.md", Size: 8},
+		{Path: "notes.txt", Filename: "notes.txt", Size: 11},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("artifacts = %+v, want %+v", got, want)
+	}
+
+	if got := collectArtifacts(nil, base); got != nil {
+		t.Fatalf("nil context: %+v", got)
+	}
+	if got := collectArtifacts(ctx, ""); got != nil {
+		t.Fatalf("empty base dir: %+v", got)
+	}
+}
+
+func TestTaskDetail_IncludesArtifacts(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+	info, err := store.Enqueue(context.Background(), "write a report", "m", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Update(info.ID, func(ti *TaskInfo) {
+		ti.Status = TaskDone
+		ti.Artifacts = []ArtifactRef{{Path: "reports/weekly.md", Filename: "weekly.md", Size: 8}}
+	})
+
+	got, ok := store.Get(info.ID)
+	if !ok {
+		t.Fatalf("task not found")
+	}
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"artifacts":[{"path":"reports/weekly.md","filename":"weekly.md","size":8}]`) {
+		t.Fatalf("task detail = %s", b)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// TaskStats is the GET /tasks/stats response: task counts by status for the
+// tasks currently retained by the store.
+type TaskStats struct {
+	Queued    int `json:"queued"`
+	Running   int `json:"running"`
+	Pending   int `json:"pending"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Canceled  int `json:"canceled"`
+	// OldestQueuedAgeSeconds is how long the oldest queued task has waited; 0 when none is queued.
+	OldestQueuedAgeSeconds float64 `json:"oldest_queued_age_seconds"`
+}
+
+// Stats counts retained tasks by status.
+func (s *TaskStore) Stats() TaskStats {
+	return s.statsAt(time.Now(), nil)
+}
+
+// StatsForLabels is Stats restricted to tasks carrying all of labels (e.g. source=cron).
+func (s *TaskStore) StatsForLabels(labels map[string]string) TaskStats {
+	return s.statsAt(time.Now(), labels)
+}
+
+func (s *TaskStore) statsAt(now time.Time, labels map[string]string) TaskStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var st TaskStats
+	var oldestQueued time.Time
+	for _, qt := range s.tasks {
+		if qt == nil || qt.info == nil || !labelsMatch(qt.info.Labels, labels) {
+			continue
+		}
+		switch qt.info.Status {
+		case TaskQueued:
+			st.Queued++
+			if oldestQueued.IsZero() || qt.info.CreatedAt.Before(oldestQueued) {
+				oldestQueued = qt.info.CreatedAt
+			}
+		case TaskRunning:
+			st.Running++
+		case TaskPending:
+			st.Pending++
+		case TaskDone:
+			st.Succeeded++
+		case TaskFailed:
+			st.Failed++
+		case TaskCanceled:
+			st.Canceled++
+		}
+	}
+	if !oldestQueued.IsZero() && now.After(oldestQueued) {
+		st.OldestQueuedAgeSeconds = now.Sub(oldestQueued).Seconds()
+	}
+	return st
+}
+
+func newTaskStatsHandler(store *TaskStore, auth string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !checkAuth(r, auth) {
+			writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "unauthorized")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(store.Stats())
+	}
+}
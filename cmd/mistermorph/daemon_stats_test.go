@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTaskStore_Stats(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	if got := store.statsAt(now, nil); got != (TaskStats{}) {
+		t.Fatalf("empty stats = %+v", got)
+	}
+
+	seed := []struct {
+		id      string
+		status  TaskStatus
+		created time.Time
+	}{
+		{"q1", TaskQueued, now.Add(-90 * time.Second)},
+		{"q2", TaskQueued, now.Add(-10 * time.Second)},
+		{"r1", TaskRunning, now.Add(-time.Hour)},
+		{"p1", TaskPending, now},
+		{"d1", TaskDone, now},
+		{"d2", TaskDone, now},
+		{"f1", TaskFailed, now},
+		{"c1", TaskCanceled, now},
+	}
+	for _, s := range seed {
+		store.Upsert(TaskInfo{ID: s.id, Status: s.status, CreatedAt: s.created})
+	}
+
+	got := store.statsAt(now, nil)
+	want := TaskStats{Queued: 2, Running: 1, Pending: 1, Succeeded: 2, Failed: 1, Canceled: 1, OldestQueuedAgeSeconds: 90}
+	if got != want {
+		t.Fatalf("stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestTaskStatsHandler(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+	store.Upsert(TaskInfo{ID: "d1", Status: TaskDone})
+	h := newTaskStatsHandler(store, "secret")
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/tasks/stats", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("no auth: status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/tasks/stats", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST: status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/tasks/stats", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET: status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	var st TaskStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if st != (TaskStats{Succeeded: 1}) {
+		t.Fatalf("stats = %+v", st)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// maxSubmitBatchItems bounds how many tasks a single POST /tasks/batch may enqueue.
+	maxSubmitBatchItems = 100
+
+	maxTaskLabels          = 16
+	maxTaskLabelKeyChars   = 64
+	maxTaskLabelValueChars = 256
+
+	maxIdempotencyKeyChars = 128
+)
+
+// submitError carries the HTTP status and API error code a submission failure should map to.
+type submitError struct {
+	code    int
+	errCode string
+	msg     string
+}
+
+func (e *submitError) Error() string { return e.msg }
+
+// submitTask validates a submission and enqueues it. existed reports an idempotent replay
+// that returned the already-retained task instead of enqueuing a new one.
+// On failure it returns a *submitError describing the HTTP status to report.
+func submitTask(store *TaskStore, req SubmitTaskRequest, defaultTimeout time.Duration, defaultModel string) (info *TaskInfo, existed bool, err error) {
+	task := strings.TrimSpace(req.Task)
+	if task == "" {
+		return nil, false, &submitError{code: http.StatusBadRequest, errCode: errCodeInvalidRequest, msg: "missing task"}
+	}
+
+	timeout := defaultTimeout
+	if strings.TrimSpace(req.Timeout) != "" {
+		if d, err := time.ParseDuration(req.Timeout); err == nil && d > 0 {
+			timeout = d
+		} else if err != nil {
+			return nil, false, &submitError{code: http.StatusBadRequest, errCode: errCodeInvalidRequest, msg: "invalid timeout (use Go duration like 2m, 30s)"}
+		}
+	}
+	model := strings.TrimSpace(req.Model)
+	if model == "" {
+		model = defaultModel
+	}
+
+	labels, err := normalizeTaskLabels(req.Labels)
+	if err != nil {
+		return nil, false, &submitError{code: http.StatusBadRequest, errCode: errCodeInvalidRequest, msg: err.Error()}
+	}
+
+	key := strings.TrimSpace(req.IdempotencyKey)
+	if len([]rune(key)) > maxIdempotencyKeyChars {
+		return nil, false, &submitError{code: http.StatusBadRequest, errCode: errCodeInvalidRequest, msg: fmt.Sprintf("idempotency_key too long (max %d chars)", maxIdempotencyKeyChars)}
+	}
+
+	info, existed, err = store.EnqueueIdempotent(context.Background(), key, task, model, timeout, labels)
+	if errors.Is(err, errIdempotencyConflict) {
+		return nil, false, &submitError{code: http.StatusConflict, errCode: errCodeConflict, msg: err.Error()}
+	}
+	if err != nil {
+		return nil, false, &submitError{code: http.StatusServiceUnavailable, errCode: errCodeQueueUnavailable, msg: err.Error()}
+	}
+	return info, existed, nil
+}
+
+// normalizeTaskLabels trims label keys/values and enforces size limits.
+func normalizeTaskLabels(in map[string]string) (map[string]string, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	if len(in) > maxTaskLabels {
+		return nil, fmt.Errorf("too many labels (max %d)", maxTaskLabels)
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		k = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+		if k == "" {
+			return nil, fmt.Errorf("invalid label: empty key")
+		}
+		if strings.Contains(k, ":") {
+			return nil, fmt.Errorf("invalid label key %q: must not contain ':'", k)
+		}
+		if len([]rune(k)) > maxTaskLabelKeyChars || len([]rune(v)) > maxTaskLabelValueChars {
+			return nil, fmt.Errorf("invalid label %q: too long", k)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+func submitErrorStatus(err error) int {
+	if se, ok := err.(*submitError); ok && se.code != 0 {
+		return se.code
+	}
+	return http.StatusInternalServerError
+}
+
+func submitErrorCode(err error) string {
+	if se, ok := err.(*submitError); ok && se.errCode != "" {
+		return se.errCode
+	}
+	return errCodeInternal
+}
+
+func writeSubmitError(w http.ResponseWriter, err error) {
+	writeError(w, submitErrorStatus(err), submitErrorCode(err), err.Error())
+}
+
+// newSubmitBatchHandler serves POST /tasks/batch. Each item is submitted independently;
+// a bad item is reported in its own result and does not fail the rest of the batch.
+func newSubmitBatchHandler(store *TaskStore, auth string, defaultTimeout func() time.Duration, defaultModel func() string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !checkAuth(r, auth) {
+			writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "unauthorized")
+			return
+		}
+		var reqs []SubmitTaskRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "invalid json (expected an array of tasks)")
+			return
+		}
+		if len(reqs) == 0 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "missing tasks")
+			return
+		}
+		if len(reqs) > maxSubmitBatchItems {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("too many tasks (max %d)", maxSubmitBatchItems))
+			return
+		}
+
+		timeout := defaultTimeout()
+		model := defaultModel()
+		resp := SubmitTaskBatchResponse{Results: make([]SubmitTaskBatchResult, 0, len(reqs))}
+		for i, item := range reqs {
+			res := SubmitTaskBatchResult{Index: i}
+			info, _, err := submitTask(store, item, timeout, model)
+			if err != nil {
+				res.Error = err.Error()
+				res.Code = submitErrorStatus(err)
+				res.ErrorCode = submitErrorCode(err)
+				resp.Failed++
+			} else {
+				res.ID = info.ID
+				res.Status = info.Status
+				resp.Accepted++
+			}
+			resp.Results = append(resp.Results, res)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestBatchHandler(store *TaskStore) http.HandlerFunc {
+	return newSubmitBatchHandler(store, "secret",
+		func() time.Duration { return time.Minute },
+		func() string { return "test-model" },
+	)
+}
+
+func TestSubmitBatch_PartialSuccess(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+	h := newTestBatchHandler(store)
+
+	body := `[{"task":"first"},{"task":"   "},{"task":"third","timeout":"nope"},{"task":"fourth","model":"m2"}]`
+	req := httptest.NewRequest(http.MethodPost, "/tasks/batch", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp SubmitTaskBatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Accepted != 2 || resp.Failed != 2 {
+		t.Fatalf("accepted=%d failed=%d, want 2/2", resp.Accepted, resp.Failed)
+	}
+	if len(resp.Results) != 4 {
+		t.Fatalf("len(results) = %d, want 4", len(resp.Results))
+	}
+
+	for _, i := range []int{0, 3} {
+		r := resp.Results[i]
+		if r.Index != i || r.ID == "" || r.Status != TaskQueued || r.Error != "" {
+			t.Fatalf("results[%d] = %+v, want queued with id", i, r)
+		}
+		if _, ok := store.Get(r.ID); !ok {
+			t.Fatalf("results[%d] id %q not found in store", i, r.ID)
+		}
+	}
+	if r := resp.Results[1]; r.ID != "" || r.Error != "missing task" || r.Code != http.StatusBadRequest {
+		t.Fatalf("results[1] = %+v, want missing task", r)
+	}
+	if r := resp.Results[2]; r.ID != "" || !strings.Contains(r.Error, "invalid timeout") || r.Code != http.StatusBadRequest {
+		t.Fatalf("results[2] = %+v, want invalid timeout", r)
+	}
+
+	info, _ := store.Get(resp.Results[3].ID)
+	if info.Model != "m2" {
+		t.Fatalf("model = %q, want m2", info.Model)
+	}
+	info, _ = store.Get(resp.Results[0].ID)
+	if info.Model != "test-model" || info.Timeout != time.Minute.String() {
+		t.Fatalf("defaults not applied: model=%q timeout=%q", info.Model, info.Timeout)
+	}
+}
+
+func TestSubmitBatch_RejectsInvalidRequests(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+	h := newTestBatchHandler(store)
+
+	tooMany := "[" + strings.TrimSuffix(strings.Repeat(`{"task":"x"},`, maxSubmitBatchItems+1), ",") + "]"
+	cases := []struct {
+		name   string
+		method string
+		auth   string
+		body   string
+		want   int
+	}{
+		{name: "method", method: http.MethodGet, auth: "Bearer secret", want: http.StatusMethodNotAllowed},
+		{name: "auth", method: http.MethodPost, auth: "Bearer wrong", body: `[{"task":"x"}]`, want: http.StatusUnauthorized},
+		{name: "not_array", method: http.MethodPost, auth: "Bearer secret", body: `{"task":"x"}`, want: http.StatusBadRequest},
+		{name: "empty", method: http.MethodPost, auth: "Bearer secret", body: `[]`, want: http.StatusBadRequest},
+		{name: "too_many", method: http.MethodPost, auth: "Bearer secret", body: tooMany, want: http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/tasks/batch", strings.NewReader(tc.body))
+			req.Header.Set("Authorization", tc.auth)
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d (body=%s)", rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSubmitTask_IdempotencyKey(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+
+	req := SubmitTaskRequest{Task: "send report", IdempotencyKey: "report-2024-06-01"}
+	first, existed, err := submitTask(store, req, time.Minute, "m")
+	if err != nil || existed {
+		t.Fatalf("first submit: existed=%v err=%v", existed, err)
+	}
+	second, existed, err := submitTask(store, req, time.Minute, "m")
+	if err != nil || !existed {
+		t.Fatalf("second submit: existed=%v err=%v", existed, err)
+	}
+	if first.ID != second.ID {
+		t.Fatalf("ids differ: %q vs %q", first.ID, second.ID)
+	}
+
+	// The same key with a different payload is a conflict, not a replay.
+	for _, changed := range []SubmitTaskRequest{
+		{Task: "send another report", IdempotencyKey: req.IdempotencyKey},
+		{Task: req.Task, Model: "other-model", IdempotencyKey: req.IdempotencyKey},
+		{Task: req.Task, Timeout: "5m", IdempotencyKey: req.IdempotencyKey},
+		{Task: req.Task, Labels: map[string]string{"source": "cron"}, IdempotencyKey: req.IdempotencyKey},
+	} {
+		_, _, err := submitTask(store, changed, time.Minute, "m")
+		if submitErrorStatus(err) != http.StatusConflict || submitErrorCode(err) != errCodeConflict {
+			t.Fatalf("submit %+v: err = %v, want 409 conflict", changed, err)
+		}
+	}
+	if n := len(store.List(TaskListFilter{})); n != 1 {
+		t.Fatalf("tasks = %d, want 1", n)
+	}
+
+	other, _, err := submitTask(store, SubmitTaskRequest{Task: "send report", IdempotencyKey: "other"}, time.Minute, "m")
+	if err != nil {
+		t.Fatalf("other key: %v", err)
+	}
+	noKey, _, err := submitTask(store, SubmitTaskRequest{Task: "send report"}, time.Minute, "m")
+	if err != nil {
+		t.Fatalf("no key: %v", err)
+	}
+	if other.ID == first.ID || noKey.ID == first.ID {
+		t.Fatalf("distinct submissions must create new tasks")
+	}
+
+	_, _, err = submitTask(store, SubmitTaskRequest{Task: "x", IdempotencyKey: strings.Repeat("k", maxIdempotencyKeyChars+1)}, time.Minute, "m")
+	if submitErrorStatus(err) != http.StatusBadRequest {
+		t.Fatalf("err = %v, want 400 for oversized key", err)
+	}
+}
+
+func TestTaskStore_IdempotencyKeyReleasedOnEviction(t *testing.T) {
+	store := NewTaskStore(10)
+	defer store.Close()
+
+	first, _, err := store.EnqueueIdempotent(context.Background(), "k", "x", "m", time.Minute, nil)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	past := time.Now().Add(-2 * defaultCompletedTTL)
+	store.Update(first.ID, func(info *TaskInfo) {
+		info.Status = TaskDone
+		info.FinishedAt = &past
+	})
+	store.evictExpired()
+
+	again, existed, err := store.EnqueueIdempotent(context.Background(), "k", "x", "m", time.Minute, nil)
+	if err != nil {
+		t.Fatalf("re-enqueue: %v", err)
+	}
+	if existed || again.ID == first.ID {
+		t.Fatalf("evicted task's key should be reusable: existed=%v id=%q", existed, again.ID)
+	}
+}
@@ -17,6 +17,11 @@ type SubmitTaskRequest struct {
 	Task    string `json:"task"`
 	Model   string `json:"model,omitempty"`
 	Timeout string `json:"timeout,omitempty"` // time.ParseDuration; optional
+	// Labels are free-form key/value tags (e.g. source=cron) used to group and filter tasks.
+	Labels map[string]string `json:"labels,omitempty"`
+	// IdempotencyKey makes retries safe: resubmitting the same key returns the
+	// existing task instead of creating a new one (while that task is retained).
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
 type SubmitTaskResponse struct {
@@ -25,17 +30,52 @@ type SubmitTaskResponse struct {
 }
 
 type TaskInfo struct {
-	ID                string     `json:"id"`
-	Status            TaskStatus `json:"status"`
-	Task              string     `json:"task"`
-	Model             string     `json:"model"`
-	Timeout           string     `json:"timeout"`
-	CreatedAt         time.Time  `json:"created_at"`
-	StartedAt         *time.Time `json:"started_at,omitempty"`
-	PendingAt         *time.Time `json:"pending_at,omitempty"`
-	ResumedAt         *time.Time `json:"resumed_at,omitempty"`
-	FinishedAt        *time.Time `json:"finished_at,omitempty"`
-	ApprovalRequestID string     `json:"approval_request_id,omitempty"`
-	Error             string     `json:"error,omitempty"`
-	Result            any        `json:"result,omitempty"`
+	ID                string            `json:"id"`
+	Status            TaskStatus        `json:"status"`
+	Task              string            `json:"task"`
+	Model             string            `json:"model"`
+	Timeout           string            `json:"timeout"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	StartedAt         *time.Time        `json:"started_at,omitempty"`
+	PendingAt         *time.Time        `json:"pending_at,omitempty"`
+	ResumedAt         *time.Time        `json:"resumed_at,omitempty"`
+	FinishedAt        *time.Time        `json:"finished_at,omitempty"`
+	ApprovalRequestID string            `json:"approval_request_id,omitempty"`
+	Error             string            `json:"error,omitempty"`
+	// LLMRetries counts provider-level retries of the run's LLM calls (e.g. a
+	// rate-limited request); the task itself runs once. LastError is the error
+	// that caused the most recent retry.
+	LLMRetries int    `json:"llm_retries"`
+	LastError  string `json:"last_error,omitempty"`
+	Result     any    `json:"result,omitempty"`
+	// Artifacts are files the run wrote under file_cache_dir.
+	Artifacts []ArtifactRef `json:"artifacts,omitempty"`
+}
+
+// ArtifactRef points at a file produced by a task. Path is relative to file_cache_dir.
+type ArtifactRef struct {
+	Path     string `json:"path"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+}
+
+type SubmitTaskBatchResult struct {
+	Index     int        `json:"index"`
+	ID        string     `json:"id,omitempty"`
+	Status    TaskStatus `json:"status,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Code      int        `json:"code,omitempty"`       // HTTP status the item would have received on its own
+	ErrorCode string     `json:"error_code,omitempty"` // same values as ErrorResponse.Code
+}
+
+type SubmitTaskBatchResponse struct {
+	Accepted int                     `json:"accepted"`
+	Failed   int                     `json:"failed"`
+	Results  []SubmitTaskBatchResult `json:"results"`
+}
+
+type TaskListResponse struct {
+	Count int        `json:"count"`
+	Tasks []TaskInfo `json:"tasks"`
 }
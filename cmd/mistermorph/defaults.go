@@ -13,10 +13,17 @@ func initViperDefaults() {
 	viper.SetDefault("llm.model", "gpt-4o-mini")
 	viper.SetDefault("llm.api_key", "")
 	viper.SetDefault("llm.request_timeout", 90*time.Second)
+	viper.SetDefault("llm.allowed_models", []string{})
+	viper.SetDefault("llm.circuit_breaker.failure_threshold", 0)
+	viper.SetDefault("llm.circuit_breaker.cooldown", 30*time.Second)
 
 	viper.SetDefault("max_steps", 15)
 	viper.SetDefault("parse_retries", 2)
 	viper.SetDefault("max_token_budget", 0)
+	viper.SetDefault("validate_tool_params", false)
+	viper.SetDefault("conclusion_token_budget", 0)
+	viper.SetDefault("reasoning_token_budget", 0)
+	viper.SetDefault("dedupe_tool_calls", 0)
 	viper.SetDefault("timeout", 10*time.Minute)
 	viper.SetDefault("plan.mode", "auto")
 
@@ -25,6 +32,9 @@ func initViperDefaults() {
 	viper.SetDefault("file_cache.max_age", 7*24*time.Hour)
 	viper.SetDefault("file_cache.max_files", 1000)
 	viper.SetDefault("file_cache.max_total_bytes", int64(512*1024*1024))
+	viper.SetDefault("file_cache.dry_run", false)
+	viper.SetDefault("file_cache.cleanup_interval", 10*time.Minute)
+	viper.SetDefault("file_cache.pinned", []string{})
 	viper.SetDefault("user_agent", "mistermorph/1.0 (+https://github.com/quailyquaily)")
 
 	// Skills
@@ -33,12 +43,16 @@ func initViperDefaults() {
 	viper.SetDefault("skills.preview_bytes", int64(2048))
 	viper.SetDefault("skills.catalog_limit", 200)
 	viper.SetDefault("skills.select_timeout", 10*time.Second)
+	viper.SetDefault("skills.remote", []any{})
+	viper.SetDefault("skills.remote_allow_insecure", false)
+	viper.SetDefault("skills.remote_ttl", 10*time.Minute)
 
 	// Daemon server
 	viper.SetDefault("server.bind", "127.0.0.1")
 	viper.SetDefault("server.port", 8787)
 	viper.SetDefault("server.max_queue", 100)
 	viper.SetDefault("server.url", "http://127.0.0.1:8787")
+	viper.SetDefault("server.metrics.enabled", false)
 
 	// Submit client
 	viper.SetDefault("submit.wait", false)
@@ -46,6 +60,8 @@ func initViperDefaults() {
 
 	// Telegram
 	viper.SetDefault("telegram.poll_timeout", 30*time.Second)
+	viper.SetDefault("telegram.denied_tools", []string{})
+	viper.SetDefault("telegram.reply_cooldown", 0*time.Second)
 	viper.SetDefault("telegram.history_max_messages", 20)
 	viper.SetDefault("telegram.aliases", []string{})
 	viper.SetDefault("telegram.group_trigger_mode", "smart")
@@ -55,7 +71,23 @@ func initViperDefaults() {
 	viper.SetDefault("telegram.addressing_llm.model", "")
 	viper.SetDefault("telegram.addressing_llm.timeout", 3*time.Second)
 	viper.SetDefault("telegram.addressing_llm.min_confidence", 0.55)
+	viper.SetDefault("telegram.addressing_llm.ack_confidence", 0.3)
+	viper.SetDefault("telegram.addressing_llm.prompt", "")
+	viper.SetDefault("telegram.addressing_llm.prompt_mode", "append")
 	viper.SetDefault("telegram.max_concurrency", 3)
+	viper.SetDefault("telegram.state_dir", "")
+	viper.SetDefault("telegram.reset_command", "/reset")
+	viper.SetDefault("telegram.soft_reaction", "👀")
+
+	// Tools: names the agent may never call, in any runtime.
+	viper.SetDefault("tools.denied", []string{})
+
+	// Voice synthesis (telegram_send_voice).
+	viper.SetDefault("tools.voice.max_chars", defaultVoiceMaxChars)
+	viper.SetDefault("tools.voice.opus_bitrate", defaultVoiceOpusBitrateKbps)
+	viper.SetDefault("tools.voice.max_concurrent_synth", defaultVoiceMaxConcurrent)
+	viper.SetDefault("tools.voice.default_lang", "")
+	viper.SetDefault("tools.voice.chat_langs", map[string]string{})
 
 	// DB (Phase 1: sqlite only)
 	viper.SetDefault("db.driver", "sqlite")
@@ -99,4 +131,11 @@ func initViperDefaults() {
 	viper.SetDefault("scheduler.enabled", false)
 	viper.SetDefault("scheduler.concurrency", 1)
 	viper.SetDefault("scheduler.tick", 60*time.Second)
+	viper.SetDefault("scheduler.max_claims_per_wake", 0)
+	viper.SetDefault("scheduler.overlap_includes_queued", true)
+	viper.SetDefault("scheduler.max_steps", 0)
+	viper.SetDefault("scheduler.max_token_budget", 0)
+	viper.SetDefault("scheduler.max_run_history_per_job", 0)
+	viper.SetDefault("scheduler.run_history_max_age", 0*time.Second)
+	viper.SetDefault("scheduler.progress_interval", 10*time.Second)
 }
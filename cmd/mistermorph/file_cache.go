@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileCachePins protects files from cache cleanup while they are still in use
+// (e.g. written by a tool but not yet delivered). Entries containing a path
+// separator match that exact path; bare names match the file's basename anywhere.
+type fileCachePins struct {
+	mu    sync.Mutex
+	paths map[string]int
+	names map[string]int
+}
+
+// defaultFileCachePins is shared by tools and the cache janitor within this process.
+var defaultFileCachePins = newFileCachePins()
+
+func newFileCachePins() *fileCachePins {
+	return &fileCachePins{
+		paths: make(map[string]int),
+		names: make(map[string]int),
+	}
+}
+
+func pinKey(p string) (key string, isPath bool) {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return "", false
+	}
+	if !strings.ContainsRune(p, filepath.Separator) && !strings.ContainsRune(p, '/') {
+		return p, false
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		p = abs
+	}
+	return filepath.Clean(p), true
+}
+
+// Pin protects a path or filename. Pins are counted, so each Pin needs a matching Unpin.
+func (p *fileCachePins) Pin(pathOrName string) {
+	key, isPath := pinKey(pathOrName)
+	if key == "" || p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if isPath {
+		p.paths[key]++
+	} else {
+		p.names[key]++
+	}
+}
+
+func (p *fileCachePins) Unpin(pathOrName string) {
+	key, isPath := pinKey(pathOrName)
+	if key == "" || p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m := p.names
+	if isPath {
+		m = p.paths
+	}
+	if m[key] <= 1 {
+		delete(m, key)
+		return
+	}
+	m[key]--
+}
+
+// IsPinned reports whether path is protected by an exact-path or basename pin.
+func (p *fileCachePins) IsPinned(path string) bool {
+	if p == nil {
+		return false
+	}
+	key, _ := pinKey(path)
+	if key == "" {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paths[key] > 0 {
+		return true
+	}
+	return p.names[filepath.Base(key)] > 0
+}
+
+// unpinDownloadedFiles releases the pins taken when files were downloaded for a job.
+func unpinDownloadedFiles(files []telegramDownloadedFile) {
+	for _, f := range files {
+		defaultFileCachePins.Unpin(f.Path)
+	}
+}
+
+// fileCacheCleanup is the janitor configuration (file_cache.*) for the cache dirs
+// the runtime writes to. It runs at startup and then every file_cache.cleanup_interval.
+type fileCacheCleanup struct {
+	Dirs          []string
+	MaxAge        time.Duration
+	MaxFiles      int
+	MaxTotalBytes int64
+	DryRun        bool
+}
+
+func (c fileCacheCleanup) run(logger *slog.Logger) {
+	for _, dir := range c.Dirs {
+		if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if c.DryRun {
+			removals, err := dryRunFileCacheCleanup(dir, c.MaxAge, c.MaxFiles, c.MaxTotalBytes)
+			if err != nil {
+				logger.Warn("file_cache_cleanup_error", "dir", dir, "error", err.Error())
+			}
+			for _, r := range removals {
+				logger.Info("file_cache_cleanup_dry_run", "path", r.Path, "bytes", r.Size, "reason", r.Reason)
+			}
+			continue
+		}
+		if err := cleanupFileCacheDir(dir, c.MaxAge, c.MaxFiles, c.MaxTotalBytes); err != nil {
+			logger.Warn("file_cache_cleanup_error", "dir", dir, "error", err.Error())
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"testing"
@@ -76,3 +78,176 @@ func TestCleanupFileCacheDir_MaxAgeAndMaxFiles(t *testing.T) {
 		t.Fatalf("expected mid file removed due to max_files")
 	}
 }
+
+func TestCleanupFileCacheDir_KeepsPinnedFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "telegram")
+	if err := ensureSecureCacheDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "chat_1")
+	if err := os.MkdirAll(sub, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	pinnedPath := filepath.Join(dir, "pending.ogg")
+	pinnedName := filepath.Join(sub, "report.pdf")
+	unpinned := filepath.Join(dir, "stale.txt")
+	released := filepath.Join(dir, "released.txt")
+	old := time.Now().Add(-48 * time.Hour)
+	for _, p := range []string{pinnedPath, pinnedName, unpinned, released} {
+		if err := os.WriteFile(p, []byte("data"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.Chtimes(p, old, old)
+	}
+
+	pins := newFileCachePins()
+	pins.Pin(pinnedPath)
+	pins.Pin("report.pdf")
+	pins.Pin(released)
+	pins.Unpin(released)
+
+	// Aggressive: everything is too old, and at most zero bytes may remain.
+	if err := cleanupFileCacheDirPinned(dir, time.Hour, 1, 1, pins); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{pinnedPath, pinnedName} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("expected pinned file %s to survive, got %v", p, err)
+		}
+	}
+	for _, p := range []string{unpinned, released} {
+		if _, err := os.Stat(p); err == nil {
+			t.Fatalf("expected unpinned file %s removed", p)
+		}
+	}
+
+	pins.Unpin(pinnedPath)
+	if pins.IsPinned(pinnedPath) {
+		t.Fatalf("expected pin released")
+	}
+	if !pins.IsPinned(filepath.Join(t.TempDir(), "report.pdf")) {
+		t.Fatalf("expected basename pin to match any directory")
+	}
+}
+
+func TestFileCacheCleanup_RunCoversTTSAndKeepsHeldPins(t *testing.T) {
+	base := t.TempDir()
+	tgDir := filepath.Join(base, "telegram")
+	ttsDir := filepath.Join(base, "tts")
+	for _, d := range []string{tgDir, ttsDir} {
+		if err := ensureSecureCacheDir(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := filepath.Join(tgDir, "stale.txt")
+	oldVoice := filepath.Join(ttsDir, "old.ogg")
+	pending := filepath.Join(ttsDir, "pending.ogg")
+	old := time.Now().Add(-48 * time.Hour)
+	for _, p := range []string{stale, oldVoice, pending} {
+		if err := os.WriteFile(p, []byte("data"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.Chtimes(p, old, old)
+	}
+	defaultFileCachePins.Pin(pending)
+	t.Cleanup(func() { defaultFileCachePins.Unpin(pending) })
+
+	c := fileCacheCleanup{
+		Dirs:   []string{tgDir, ttsDir, filepath.Join(base, "missing")},
+		MaxAge: time.Hour,
+	}
+	c.run(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, p := range []string{stale, oldVoice} {
+		if _, err := os.Stat(p); err == nil {
+			t.Fatalf("expected %s removed", p)
+		}
+	}
+	if _, err := os.Stat(pending); err != nil {
+		t.Fatalf("expected pinned file to survive, got %v", err)
+	}
+}
+
+func TestFileCachePins_Counted(t *testing.T) {
+	pins := newFileCachePins()
+	p := filepath.Join(t.TempDir(), "a.txt")
+	pins.Pin(p)
+	pins.Pin(p)
+	pins.Unpin(p)
+	if !pins.IsPinned(p) {
+		t.Fatalf("expected still pinned after one of two unpins")
+	}
+	pins.Unpin(p)
+	if pins.IsPinned(p) {
+		t.Fatalf("expected unpinned")
+	}
+}
+
+func TestDryRunFileCacheCleanup_MatchesRealRun(t *testing.T) {
+	mk := func(t *testing.T) (string, map[string]string) {
+		dir := filepath.Join(t.TempDir(), "telegram")
+		if err := ensureSecureCacheDir(dir); err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now()
+		files := map[string]struct {
+			age  time.Duration
+			size int
+		}{
+			"ancient.bin": {age: 10 * time.Hour, size: 10},
+			"old.bin":     {age: 2 * time.Hour, size: 50},
+			"mid.bin":     {age: time.Hour, size: 50},
+			"recent.bin":  {age: 30 * time.Minute, size: 50},
+			"newest.bin":  {age: time.Minute, size: 50},
+		}
+		paths := make(map[string]string)
+		for name, f := range files {
+			p := filepath.Join(dir, name)
+			if err := os.WriteFile(p, make([]byte, f.size), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			ts := now.Add(-f.age)
+			_ = os.Chtimes(p, ts, ts)
+			paths[name] = p
+		}
+		return dir, paths
+	}
+
+	dir, paths := mk(t)
+	// ancient: age; old: file_count (4 > 3); mid: total_bytes (150 > 100).
+	removals, err := planFileCacheCleanup(dir, 3*time.Hour, 3, 100, newFileCachePins())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		paths["ancient.bin"]: fileCacheRemovalAge,
+		paths["old.bin"]:     fileCacheRemovalFileCount,
+		paths["mid.bin"]:     fileCacheRemovalTotalBytes,
+	}
+	if len(removals) != len(want) {
+		t.Fatalf("removals = %+v, want %d entries", removals, len(want))
+	}
+	for _, r := range removals {
+		if want[r.Path] != r.Reason {
+			t.Fatalf("removal %s reason = %q, want %q", r.Path, r.Reason, want[r.Path])
+		}
+	}
+	// Dry run must not delete anything.
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("dry run removed %s: %v", p, err)
+		}
+	}
+
+	// A real run over the same inputs removes exactly the reported files.
+	if err := cleanupFileCacheDirPinned(dir, 3*time.Hour, 3, 100, newFileCachePins()); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range paths {
+		_, err := os.Stat(p)
+		if _, removed := want[p]; removed != (err != nil) {
+			t.Fatalf("real run mismatch for %s: reported=%v statErr=%v", p, removed, err)
+		}
+	}
+}
@@ -138,8 +138,9 @@ func registryFromViper() *tools.Registry {
 	}
 
 	if viper.GetBool("scheduler.enabled") {
-		r.Register(builtin.NewScheduleJobTool(viper.GetString("db.dsn")))
+		r.Register(builtin.NewScheduleJobToolWithOptions(viper.GetString("db.dsn"), viper.GetStringSlice("llm.allowed_models")))
 		r.Register(builtin.NewListJobsTool(viper.GetString("db.dsn")))
+		r.Register(builtin.NewFailedRunsTool(viper.GetString("db.dsn")))
 		r.Register(builtin.NewSearchJobsTool(viper.GetString("db.dsn")))
 		r.Register(builtin.NewUnscheduleJobTool(viper.GetString("db.dsn")))
 	}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/signal"
 	"sort"
@@ -54,6 +55,7 @@ func newRunCmd() *cobra.Command {
 				Endpoint:       endpoint,
 				APIKey:         apiKey,
 				RequestTimeout: flagOrViperDuration(cmd, "llm-request-timeout", "llm.request_timeout"),
+				Breaker:        llmBreakerConfigFromViper(),
 			})
 			if err != nil {
 				return err
@@ -99,15 +101,20 @@ func newRunCmd() *cobra.Command {
 			if g := guardFromViper(logger); g != nil {
 				opts = append(opts, agent.WithGuard(g))
 			}
+			opts = append(opts, agent.WithDeniedTools(deniedToolsFromViper()))
 
 			engine := agent.New(
 				client,
 				registryFromViper(),
 				agent.Config{
-					MaxSteps:       flagOrViperInt(cmd, "max-steps", "max_steps"),
-					ParseRetries:   flagOrViperInt(cmd, "parse-retries", "parse_retries"),
-					MaxTokenBudget: flagOrViperInt(cmd, "max-token-budget", "max_token_budget"),
-					PlanMode:       strings.TrimSpace(flagOrViperString(cmd, "plan-mode", "plan.mode")),
+					MaxSteps:              flagOrViperInt(cmd, "max-steps", "max_steps"),
+					ParseRetries:          flagOrViperInt(cmd, "parse-retries", "parse_retries"),
+					MaxTokenBudget:        flagOrViperInt(cmd, "max-token-budget", "max_token_budget"),
+					PlanMode:              strings.TrimSpace(flagOrViperString(cmd, "plan-mode", "plan.mode")),
+					ValidateToolParams:    viper.GetBool("validate_tool_params"),
+					ConclusionTokenBudget: viper.GetInt("conclusion_token_budget"),
+					ReasoningTokenBudget:  viper.GetInt("reasoning_token_budget"),
+					DedupeToolCalls:       viper.GetInt("dedupe_tool_calls"),
 				},
 				promptSpec,
 				opts...,
@@ -177,6 +184,25 @@ type llmClientConfig struct {
 	Endpoint       string
 	APIKey         string
 	RequestTimeout time.Duration
+	// Breaker wraps the client in an llm.CircuitBreaker when FailureThreshold > 0.
+	Breaker llm.BreakerConfig
+}
+
+// deniedToolsFromViper returns tools.denied plus the lists under extraKeys
+// (e.g. "telegram.denied_tools" for chat runs).
+func deniedToolsFromViper(extraKeys ...string) []string {
+	out := viper.GetStringSlice("tools.denied")
+	for _, k := range extraKeys {
+		out = append(out, viper.GetStringSlice(k)...)
+	}
+	return out
+}
+
+func llmBreakerConfigFromViper() llm.BreakerConfig {
+	return llm.BreakerConfig{
+		FailureThreshold: viper.GetInt("llm.circuit_breaker.failure_threshold"),
+		Cooldown:         viper.GetDuration("llm.circuit_breaker.cooldown"),
+	}
 }
 
 func llmClientFromConfig(cfg llmClientConfig) (llm.Client, error) {
@@ -186,7 +212,7 @@ func llmClientFromConfig(cfg llmClientConfig) (llm.Client, error) {
 		if cfg.RequestTimeout > 0 && c.HTTP != nil {
 			c.HTTP.Timeout = cfg.RequestTimeout
 		}
-		return c, nil
+		return llm.NewCircuitBreaker(c, cfg.Breaker), nil
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", cfg.Provider)
 	}
@@ -194,6 +220,13 @@ func llmClientFromConfig(cfg llmClientConfig) (llm.Client, error) {
 
 var errAbortedByUser = errors.New("aborted by user")
 
+// skillCache re-reads SKILL.md files only when they change, so long-running modes
+// (serve/telegram) pick up skill edits on the next run.
+var skillCache = skills.NewCache()
+
+// skillUsage counts skill loads across runs in this process (reported by /overview).
+var skillUsage = skills.NewUsage()
+
 func promptSpecWithSkills(ctx context.Context, log *slog.Logger, logOpts agent.LogOptions, task string, client llm.Client, model string, cfg skillsConfig) (agent.PromptSpec, []string, []string, error) {
 	if log == nil {
 		log = slog.Default()
@@ -202,7 +235,7 @@ func promptSpecWithSkills(ctx context.Context, log *slog.Logger, logOpts agent.L
 	var loadedOrdered []string
 	declaredAuthProfiles := make(map[string]bool)
 
-	discovered, err := skills.Discover(skills.DiscoverOptions{Roots: cfg.Roots})
+	discovered, err := skills.Discover(skills.DiscoverOptions{Roots: skillRootsWithRemote(ctx, log, cfg)})
 	if err != nil {
 		if cfg.Trace {
 			log.Warn("skills_discover_warning", "error", err.Error())
@@ -254,7 +287,7 @@ func promptSpecWithSkills(ctx context.Context, log *slog.Logger, logOpts agent.L
 		if loadedSkillIDs[strings.ToLower(s.ID)] {
 			continue
 		}
-		skillLoaded, err := skills.Load(s, 512*1024)
+		skillLoaded, err := skillCache.Load(s, 512*1024)
 		if err != nil {
 			return agent.PromptSpec{}, nil, nil, err
 		}
@@ -269,6 +302,7 @@ func promptSpecWithSkills(ctx context.Context, log *slog.Logger, logOpts agent.L
 		})
 
 		log.Info("skill_loaded", "mode", mode, "name", skillLoaded.Name, "id", skillLoaded.ID, "path", skillLoaded.SkillMD, "bytes", len(skillLoaded.Contents))
+		skillUsage.RecordLoad(skillLoaded.ID, skills.UsageExplicit)
 		if logOpts.IncludeSkillContents {
 			log.Debug("skill_contents", "id", skillLoaded.ID, "content", truncateString(skillLoaded.Contents, logOpts.MaxSkillContentChars))
 		}
@@ -344,7 +378,7 @@ func promptSpecWithSkills(ctx context.Context, log *slog.Logger, logOpts agent.L
 			if loadedSkillIDs[strings.ToLower(s.ID)] {
 				continue
 			}
-			skillLoaded, err := skills.Load(s, 512*1024)
+			skillLoaded, err := skillCache.Load(s, 512*1024)
 			if err != nil {
 				continue
 			}
@@ -358,6 +392,7 @@ func promptSpecWithSkills(ctx context.Context, log *slog.Logger, logOpts agent.L
 				Content: skillLoaded.Contents,
 			})
 			log.Info("skill_loaded", "mode", mode, "name", skillLoaded.Name, "id", skillLoaded.ID, "path", skillLoaded.SkillMD, "bytes", len(skillLoaded.Contents))
+			skillUsage.RecordLoad(skillLoaded.ID, skills.UsageSelected)
 			if logOpts.IncludeSkillContents {
 				log.Debug("skill_contents", "id", skillLoaded.ID, "content", truncateString(skillLoaded.Contents, logOpts.MaxSkillContentChars))
 			}
@@ -383,6 +418,44 @@ func promptSpecWithSkills(ctx context.Context, log *slog.Logger, logOpts agent.L
 	return spec, loadedOrdered, ap, nil
 }
 
+// skillRootsWithRemote syncs configured remote skill libraries and appends their cache dirs
+// after the local roots. A failed sync keeps the last cached copy (if any) and logs a warning.
+func skillRootsWithRemote(ctx context.Context, log *slog.Logger, cfg skillsConfig) []string {
+	if len(cfg.Remote) == 0 {
+		return cfg.Roots
+	}
+	roots := append([]string{}, cfg.Roots...)
+	if len(roots) == 0 {
+		roots = skills.DefaultRoots()
+	}
+	for _, src := range cfg.Remote {
+		dir, err := skills.SyncRemote(ctx, src, skills.RemoteOptions{
+			CacheDir:      cfg.RemoteCacheDir,
+			AllowInsecure: cfg.RemoteAllowInsecure,
+			TTL:           cfg.RemoteTTL,
+		})
+		if err != nil {
+			log.Warn("skills_remote_sync_error", "url", sanitizeSkillSourceURL(src.URL), "cached", dir != "", "error", err.Error())
+		}
+		if dir != "" {
+			roots = append(roots, dir)
+		}
+	}
+	return roots
+}
+
+// sanitizeSkillSourceURL drops userinfo and query strings (which may carry tokens) for logging.
+func sanitizeSkillSourceURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
 func newInteractiveHook() (agent.Hook, error) {
 	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
 	if err != nil {
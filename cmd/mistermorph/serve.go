@@ -56,6 +56,7 @@ func newServeCmd() *cobra.Command {
 				Endpoint:       llmEndpointFromViper(),
 				APIKey:         llmAPIKeyFromViper(),
 				RequestTimeout: viper.GetDuration("llm.request_timeout"),
+				Breaker:        llmBreakerConfigFromViper(),
 			})
 			if err != nil {
 				return err
@@ -65,20 +66,35 @@ func newServeCmd() *cobra.Command {
 			logOpts := logOptionsFromViper()
 
 			baseCfg := agent.Config{
-				MaxSteps:       viper.GetInt("max_steps"),
-				ParseRetries:   viper.GetInt("parse_retries"),
-				MaxTokenBudget: viper.GetInt("max_token_budget"),
-				PlanMode:       viper.GetString("plan.mode"),
+				MaxSteps:              viper.GetInt("max_steps"),
+				ParseRetries:          viper.GetInt("parse_retries"),
+				MaxTokenBudget:        viper.GetInt("max_token_budget"),
+				PlanMode:              viper.GetString("plan.mode"),
+				ValidateToolParams:    viper.GetBool("validate_tool_params"),
+				ConclusionTokenBudget: viper.GetInt("conclusion_token_budget"),
+				ReasoningTokenBudget:  viper.GetInt("reasoning_token_budget"),
+				DedupeToolCalls:       viper.GetInt("dedupe_tool_calls"),
 			}
 
 			sharedGuard := guardFromViper(logger)
 
+			depChecks := map[string]dependencyCheck{
+				"llm": llmEndpointCheck(llmEndpointFromViper()),
+			}
+
 			if viper.GetBool("scheduler.enabled") {
 				dbCfg := dbConfigFromViper()
 				gdb, err := db.Open(cmd.Context(), dbCfg)
 				if err != nil {
 					return err
 				}
+				depChecks["db"] = func(ctx context.Context) error {
+					sqlDB, err := gdb.DB()
+					if err != nil {
+						return err
+					}
+					return sqlDB.PingContext(ctx)
+				}
 				if dbCfg.AutoMigrate {
 					if err := db.AutoMigrate(gdb); err != nil {
 						return err
@@ -89,9 +105,15 @@ func newServeCmd() *cobra.Command {
 				schedCfg.Enabled = true
 				schedCfg.Concurrency = viper.GetInt("scheduler.concurrency")
 				schedCfg.Tick = viper.GetDuration("scheduler.tick")
+				schedCfg.MaxClaimsPerWake = viper.GetInt("scheduler.max_claims_per_wake")
+				schedCfg.OverlapIncludesQueued = viper.GetBool("scheduler.overlap_includes_queued")
+				schedCfg.MaxRunHistoryPerJob = viper.GetInt("scheduler.max_run_history_per_job")
+				schedCfg.RunHistoryMaxAge = viper.GetDuration("scheduler.run_history_max_age")
+				schedCfg.ProgressInterval = viper.GetDuration("scheduler.progress_interval")
+				mirrorCronRunsToStore(&schedCfg, store, llmModelFromViper())
 
 				runner := func(ctx context.Context, task string, model string, meta map[string]any) (*string, error) {
-					final, runCtx, err := runOneTask(ctx, logger, logOpts, client, reg, baseCfg, sharedGuard, task, model, meta)
+					final, runCtx, err := runOneTask(ctx, logger, logOpts, client, reg, baseCfg, sharedGuard, task, cronRunOptions(model, meta))
 					if err != nil {
 						return nil, err
 					}
@@ -148,11 +170,12 @@ func newServeCmd() *cobra.Command {
 						runErr error
 					)
 
+					taskCtx := llm.WithRetryObserver(qt.ctx, func(err error) { store.RecordRetry(id, err) })
 					if resumeApprovalID != "" {
 						qt.resumeApprovalID = ""
-						final, runCtx, runErr = resumeOneTask(qt.ctx, logger, logOpts, client, reg, baseCfg, sharedGuard, resumeApprovalID)
+						final, runCtx, runErr = resumeOneTask(taskCtx, logger, logOpts, client, reg, baseCfg, sharedGuard, resumeApprovalID)
 					} else {
-						final, runCtx, runErr = runOneTask(qt.ctx, logger, logOpts, client, reg, baseCfg, sharedGuard, qt.info.Task, qt.info.Model, nil)
+						final, runCtx, runErr = runOneTask(taskCtx, logger, logOpts, client, reg, baseCfg, sharedGuard, qt.info.Task, agent.RunOptions{Model: qt.info.Model})
 					}
 
 					if pendingID, ok := pendingApprovalID(final); ok && runErr == nil {
@@ -181,86 +204,84 @@ func newServeCmd() *cobra.Command {
 								info.Status = TaskFailed
 							}
 							info.Error = runErr.Error()
+							info.LastError = runErr.Error()
 							return
 						}
 						info.Status = TaskDone
-						info.Result = map[string]any{
+						result := map[string]any{
 							"final":   final,
 							"metrics": runCtx.Metrics,
 							"steps":   summarizeSteps(runCtx),
 						}
+						if len(runCtx.ToolErrors) > 0 {
+							result["tool_errors"] = runCtx.ToolErrors
+						}
+						info.Result = result
+						info.Artifacts = collectArtifacts(runCtx, viper.GetString("file_cache_dir"))
 					})
 					qt.cancel()
 				}
 			}()
 
 			mux := http.NewServeMux()
-			mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-				_ = json.NewEncoder(w).Encode(map[string]any{
-					"ok":   true,
-					"time": time.Now().Format(time.RFC3339Nano),
-				})
-			})
+			mux.HandleFunc("/health", handleHealth)
+			mux.HandleFunc("/overview", newOverviewHandler(auth, "serve", depChecks, defaultDependencyCheckTimeout))
 			mux.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodPost {
-					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				if r.Method != http.MethodPost && r.Method != http.MethodGet {
+					writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 					return
 				}
 				if !checkAuth(r, auth) {
-					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "unauthorized")
 					return
 				}
-				var req SubmitTaskRequest
-				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-					http.Error(w, "invalid json", http.StatusBadRequest)
+				if r.Method == http.MethodGet {
+					handleListTasks(w, r, store)
 					return
 				}
-				req.Task = strings.TrimSpace(req.Task)
-				if req.Task == "" {
-					http.Error(w, "missing task", http.StatusBadRequest)
+				var req SubmitTaskRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "invalid json")
 					return
 				}
-
-				timeout := viper.GetDuration("timeout")
-				if strings.TrimSpace(req.Timeout) != "" {
-					if d, err := time.ParseDuration(req.Timeout); err == nil && d > 0 {
-						timeout = d
-					} else if err != nil {
-						http.Error(w, "invalid timeout (use Go duration like 2m, 30s)", http.StatusBadRequest)
-						return
-					}
-				}
-				model := strings.TrimSpace(req.Model)
-				if model == "" {
-					model = llmModelFromViper()
-				}
-
-				info, err := store.Enqueue(context.Background(), req.Task, model, timeout)
+				info, existed, err := submitTask(store, req, viper.GetDuration("timeout"), llmModelFromViper())
 				if err != nil {
-					http.Error(w, err.Error(), http.StatusServiceUnavailable)
+					writeSubmitError(w, err)
 					return
 				}
 				w.Header().Set("Content-Type", "application/json")
+				// 201 for a newly queued task, 200 when an idempotency key replays an existing one.
+				if !existed {
+					w.WriteHeader(http.StatusCreated)
+				}
 				_ = json.NewEncoder(w).Encode(SubmitTaskResponse{ID: info.ID, Status: info.Status})
 			})
+			mux.HandleFunc("/tasks/batch", newSubmitBatchHandler(store, auth,
+				func() time.Duration { return viper.GetDuration("timeout") },
+				llmModelFromViper,
+			))
+			mux.HandleFunc("/tasks/stats", newTaskStatsHandler(store, auth))
+			if viper.GetBool("server.metrics.enabled") {
+				mux.HandleFunc("/metrics", newMetricsHandler(store, auth))
+			}
 			mux.HandleFunc("/tasks/", func(w http.ResponseWriter, r *http.Request) {
 				if r.Method != http.MethodGet {
-					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 					return
 				}
 				if !checkAuth(r, auth) {
-					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "unauthorized")
 					return
 				}
 				id := strings.TrimPrefix(r.URL.Path, "/tasks/")
 				id = strings.TrimSpace(id)
 				if id == "" {
-					http.Error(w, "missing id", http.StatusBadRequest)
+					writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "missing id")
 					return
 				}
 				info, ok := store.Get(id)
 				if !ok {
-					http.NotFound(w, r)
+					writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
 					return
 				}
 				w.Header().Set("Content-Type", "application/json")
@@ -269,23 +290,23 @@ func newServeCmd() *cobra.Command {
 
 			mux.HandleFunc("/approvals/", func(w http.ResponseWriter, r *http.Request) {
 				if !checkAuth(r, auth) {
-					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "unauthorized")
 					return
 				}
 				if sharedGuard == nil || !sharedGuard.Enabled() {
-					http.Error(w, "guard is not enabled", http.StatusBadRequest)
+					writeError(w, http.StatusBadRequest, errCodeGuardDisabled, "guard is not enabled")
 					return
 				}
 				path := strings.TrimPrefix(r.URL.Path, "/approvals/")
 				path = strings.Trim(path, "/")
 				if path == "" {
-					http.Error(w, "missing approval id", http.StatusBadRequest)
+					writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "missing approval id")
 					return
 				}
 				parts := strings.Split(path, "/")
 				id := strings.TrimSpace(parts[0])
 				if id == "" {
-					http.Error(w, "missing approval id", http.StatusBadRequest)
+					writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "missing approval id")
 					return
 				}
 
@@ -298,11 +319,11 @@ func newServeCmd() *cobra.Command {
 				case r.Method == http.MethodGet && len(parts) == 1:
 					rec, ok, err := sharedGuard.GetApproval(r.Context(), id)
 					if err != nil {
-						http.Error(w, err.Error(), http.StatusInternalServerError)
+						writeError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
 						return
 					}
 					if !ok {
-						http.NotFound(w, r)
+						writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
 						return
 					}
 					// Never return resume_state in the daemon API.
@@ -331,7 +352,7 @@ func newServeCmd() *cobra.Command {
 					var req resolveReq
 					_ = json.NewDecoder(r.Body).Decode(&req)
 					if err := sharedGuard.ResolveApproval(r.Context(), id, guard.ApprovalApproved, req.Actor, req.Comment); err != nil {
-						http.Error(w, err.Error(), http.StatusBadRequest)
+						writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 						return
 					}
 					w.Header().Set("Content-Type", "application/json")
@@ -342,7 +363,7 @@ func newServeCmd() *cobra.Command {
 					var req resolveReq
 					_ = json.NewDecoder(r.Body).Decode(&req)
 					if err := sharedGuard.ResolveApproval(r.Context(), id, guard.ApprovalDenied, req.Actor, req.Comment); err != nil {
-						http.Error(w, err.Error(), http.StatusBadRequest)
+						writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 						return
 					}
 					taskID, _ := store.FailPendingByApprovalID(id, "approval denied")
@@ -353,27 +374,27 @@ func newServeCmd() *cobra.Command {
 				case r.Method == http.MethodPost && len(parts) == 2 && parts[1] == "resume":
 					rec, ok, err := sharedGuard.GetApproval(r.Context(), id)
 					if err != nil {
-						http.Error(w, err.Error(), http.StatusInternalServerError)
+						writeError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
 						return
 					}
 					if !ok {
-						http.NotFound(w, r)
+						writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
 						return
 					}
 					if rec.Status != guard.ApprovalApproved {
-						http.Error(w, "approval is not approved", http.StatusConflict)
+						writeError(w, http.StatusConflict, errCodeConflict, "approval is not approved")
 						return
 					}
 					taskID, err := store.EnqueueResumeByApprovalID(id)
 					if err != nil {
-						http.Error(w, err.Error(), http.StatusConflict)
+						writeError(w, http.StatusConflict, errCodeConflict, err.Error())
 						return
 					}
 					w.Header().Set("Content-Type", "application/json")
 					_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "status": "queued", "task_id": taskID})
 					return
 				default:
-					http.Error(w, "not found", http.StatusNotFound)
+					writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
 					return
 				}
 			})
@@ -446,8 +467,8 @@ func errorsIsContextDeadline(ctx context.Context, err error) bool {
 	return strings.Contains(strings.ToLower(err.Error()), "context deadline exceeded")
 }
 
-func runOneTask(ctx context.Context, logger *slog.Logger, logOpts agent.LogOptions, client llm.Client, registry *tools.Registry, baseCfg agent.Config, sharedGuard *guard.Guard, task string, model string, meta map[string]any) (*agent.Final, *agent.Context, error) {
-	promptSpec, _, skillAuthProfiles, err := promptSpecWithSkills(ctx, logger, logOpts, task, client, model, skillsConfigFromViper(model))
+func runOneTask(ctx context.Context, logger *slog.Logger, logOpts agent.LogOptions, client llm.Client, registry *tools.Registry, baseCfg agent.Config, sharedGuard *guard.Guard, task string, opts agent.RunOptions) (*agent.Final, *agent.Context, error) {
+	promptSpec, _, skillAuthProfiles, err := promptSpecWithSkills(ctx, logger, logOpts, task, client, opts.Model, skillsConfigFromViper(opts.Model))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -460,8 +481,10 @@ func runOneTask(ctx context.Context, logger *slog.Logger, logOpts agent.LogOptio
 		agent.WithLogOptions(logOpts),
 		agent.WithSkillAuthProfiles(skillAuthProfiles, viper.GetBool("secrets.require_skill_profiles")),
 		agent.WithGuard(sharedGuard),
+		agent.WithDeniedTools(deniedToolsFromViper()),
+		agent.WithHook(cronProgressHook),
 	)
-	return engine.Run(ctx, task, agent.RunOptions{Model: model, Meta: meta})
+	return engine.Run(ctx, task, opts)
 }
 
 func resumeOneTask(ctx context.Context, logger *slog.Logger, logOpts agent.LogOptions, client llm.Client, registry *tools.Registry, baseCfg agent.Config, sharedGuard *guard.Guard, approvalRequestID string) (*agent.Final, *agent.Context, error) {
@@ -473,6 +496,7 @@ func resumeOneTask(ctx context.Context, logger *slog.Logger, logOpts agent.LogOp
 		agent.WithLogger(logger),
 		agent.WithLogOptions(logOpts),
 		agent.WithGuard(sharedGuard),
+		agent.WithDeniedTools(deniedToolsFromViper()),
 	)
 	return engine.Resume(ctx, approvalRequestID)
 }
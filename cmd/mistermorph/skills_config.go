@@ -1,9 +1,12 @@
 package main
 
 import (
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/quailyquaily/mistermorph/skills"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -19,6 +22,12 @@ type skillsConfig struct {
 	SelectTimeout time.Duration
 	SelectorModel string
 	Trace         bool
+
+	// Remote skill libraries, merged after local roots (local skills win on duplicate ids).
+	Remote              []skills.RemoteSource
+	RemoteAllowInsecure bool
+	RemoteTTL           time.Duration
+	RemoteCacheDir      string
 }
 
 func skillsConfigFromViper(model string) skillsConfig {
@@ -40,6 +49,11 @@ func skillsConfigFromViper(model string) skillsConfig {
 		SelectTimeout: viper.GetDuration("skills.select_timeout"),
 		SelectorModel: strings.TrimSpace(viper.GetString("skills.selector_model")),
 		Trace:         viper.GetBool("trace"),
+
+		Remote:              remoteSkillSourcesFromViper(),
+		RemoteAllowInsecure: viper.GetBool("skills.remote_allow_insecure"),
+		RemoteTTL:           viper.GetDuration("skills.remote_ttl"),
+		RemoteCacheDir:      filepath.Join(strings.TrimSpace(viper.GetString("file_cache_dir")), "skills_remote"),
 	}
 	cfg.Requested = append(cfg.Requested, getStringSlice("skills.load")...)
 	if strings.TrimSpace(cfg.Mode) == "" {
@@ -51,6 +65,30 @@ func skillsConfigFromViper(model string) skillsConfig {
 	return cfg
 }
 
+// remoteSkillSourcesFromViper reads skills.remote, accepting plain URL strings or {url, sha256} maps.
+func remoteSkillSourcesFromViper() []skills.RemoteSource {
+	raw, ok := viper.Get("skills.remote").([]any)
+	if !ok {
+		return nil
+	}
+	var out []skills.RemoteSource
+	for _, item := range raw {
+		switch v := item.(type) {
+		case string:
+			if u := strings.TrimSpace(v); u != "" {
+				out = append(out, skills.RemoteSource{URL: u})
+			}
+		case map[string]any:
+			u, _ := v["url"].(string)
+			sum, _ := v["sha256"].(string)
+			if u = strings.TrimSpace(u); u != "" {
+				out = append(out, skills.RemoteSource{URL: u, SHA256: strings.TrimSpace(sum)})
+			}
+		}
+	}
+	return out
+}
+
 func skillsAutoFromViper() bool {
 	if viper.IsSet("skills.auto") {
 		return viper.GetBool("skills.auto")
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quailyquaily/mistermorph/agent"
+)
+
+func TestPromptSpecWithSkills_RecordsUsage(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"alpha-usage", "beta-usage"} {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("# "+name+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before := skillUsage.Snapshot()
+	cfg := skillsConfig{Roots: []string{root}, Mode: "explicit", Requested: []string{"alpha-usage"}}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	_, loaded, _, err := promptSpecWithSkills(context.Background(), log, agent.DefaultLogOptions(), "task", nil, "m", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0] != "alpha-usage" {
+		t.Fatalf("loaded = %v", loaded)
+	}
+
+	after := skillUsage.Snapshot()
+	if got := after["alpha-usage"].Explicit - before["alpha-usage"].Explicit; got != 1 {
+		t.Fatalf("alpha explicit delta = %d, want 1", got)
+	}
+	if got := after["beta-usage"].Loads - before["beta-usage"].Loads; got != 0 {
+		t.Fatalf("beta loads delta = %d, want 0", got)
+	}
+}
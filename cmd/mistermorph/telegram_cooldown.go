@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// telegramReplyCooldown tracks when the bot last replied in each chat. While a chat
+// is cooling down (telegram.reply_cooldown), only explicit triggers get a reply.
+type telegramReplyCooldown struct {
+	d time.Duration
+
+	mu   sync.Mutex
+	last map[int64]time.Time
+}
+
+func newTelegramReplyCooldown(d time.Duration) *telegramReplyCooldown {
+	return &telegramReplyCooldown{d: d, last: make(map[int64]time.Time)}
+}
+
+func (c *telegramReplyCooldown) markReplied(chatID int64, now time.Time) {
+	if c == nil || c.d <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last[chatID] = now
+	// Drop expired entries so the map stays bounded by recently active chats.
+	for id, t := range c.last {
+		if now.Sub(t) >= c.d {
+			delete(c.last, id)
+		}
+	}
+}
+
+func (c *telegramReplyCooldown) active(chatID int64, now time.Time) bool {
+	if c == nil || c.d <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.last[chatID]
+	return ok && now.Sub(t) < c.d
+}
+
+// suppresses reports whether a group message with trigger decision (dec, ok) should
+// be dropped because the chat is cooling down. Replies to the bot and mentions still
+// trigger; alias hits and borderline messages (addressing LLM) do not.
+func (c *telegramReplyCooldown) suppresses(chatID int64, dec telegramGroupTriggerDecision, ok bool, now time.Time) bool {
+	if !c.active(chatID, now) {
+		return false
+	}
+	return !ok || !isExplicitTriggerReason(dec.Reason)
+}
+
+func isExplicitTriggerReason(reason string) bool {
+	switch reason {
+	case "reply", "text_mention", "mention_entity", "at_mention":
+		return true
+	}
+	return false
+}
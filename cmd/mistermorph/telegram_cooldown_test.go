@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTelegramReplyCooldown_Suppresses(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := newTelegramReplyCooldown(time.Minute)
+	c.markReplied(1, now)
+
+	cases := []struct {
+		name   string
+		chatID int64
+		at     time.Time
+		dec    telegramGroupTriggerDecision
+		ok     bool
+		want   bool
+	}{
+		{name: "reply_during_cooldown", chatID: 1, at: now.Add(10 * time.Second), dec: telegramGroupTriggerDecision{Reason: "reply"}, ok: true, want: false},
+		{name: "mention_during_cooldown", chatID: 1, at: now.Add(10 * time.Second), dec: telegramGroupTriggerDecision{Reason: "at_mention"}, ok: true, want: false},
+		{name: "mention_entity_during_cooldown", chatID: 1, at: now.Add(10 * time.Second), dec: telegramGroupTriggerDecision{Reason: "mention_entity"}, ok: true, want: false},
+		{name: "alias_during_cooldown", chatID: 1, at: now.Add(10 * time.Second), dec: telegramGroupTriggerDecision{Reason: "alias_smart:morph"}, ok: true, want: true},
+		{name: "borderline_during_cooldown", chatID: 1, at: now.Add(10 * time.Second), dec: telegramGroupTriggerDecision{Reason: "alias_uncertain:morph", NeedsAddressingLLM: true}, ok: false, want: true},
+		{name: "borderline_after_cooldown", chatID: 1, at: now.Add(time.Minute), dec: telegramGroupTriggerDecision{Reason: "alias_uncertain:morph", NeedsAddressingLLM: true}, ok: false, want: false},
+		{name: "alias_other_chat", chatID: 2, at: now.Add(10 * time.Second), dec: telegramGroupTriggerDecision{Reason: "alias_smart:morph"}, ok: true, want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := c.suppresses(tc.chatID, tc.dec, tc.ok, tc.at); got != tc.want {
+				t.Fatalf("suppresses = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTelegramReplyCooldown_Disabled(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := newTelegramReplyCooldown(0)
+	c.markReplied(1, now)
+	dec := telegramGroupTriggerDecision{Reason: "alias_uncertain:morph", NeedsAddressingLLM: true}
+	if c.suppresses(1, dec, false, now) {
+		t.Fatalf("disabled cooldown suppressed a message")
+	}
+}
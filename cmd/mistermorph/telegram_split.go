@@ -0,0 +1,131 @@
+package main
+
+import "strings"
+
+// telegramChunkLimit is the per-message size budget in UTF-16 code units, with
+// headroom below Telegram's hard limit of 4096.
+const telegramChunkLimit = 3500
+
+// splitTelegramMessage splits text into chunks of at most limit UTF-16 code units,
+// preferring newline boundaries. A fenced code block that spans chunks is closed at
+// the end of one chunk and re-opened (with its language tag) at the start of the
+// next, so every chunk is valid Markdown on its own.
+func splitTelegramMessage(text string, limit int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if limit <= 0 {
+		limit = telegramChunkLimit
+	}
+	if utf16Len(text) <= limit {
+		return []string{text}
+	}
+
+	const closeFence = "\n```"
+	var (
+		chunks  []string
+		cur     strings.Builder
+		curLen  int
+		openTag string // fence line of the code block we're in, e.g. "```go"; "" outside code
+	)
+	flush := func() {
+		s := strings.TrimRight(cur.String(), "\n")
+		if openTag != "" {
+			if strings.TrimSpace(s) == openTag {
+				s = "" // nothing but the re-opened fence
+			} else {
+				s += closeFence
+			}
+		}
+		if strings.TrimSpace(s) != "" {
+			chunks = append(chunks, s)
+		}
+		cur.Reset()
+		curLen = 0
+		if openTag != "" {
+			cur.WriteString(openTag + "\n")
+			curLen = utf16Len(openTag) + 1
+		}
+	}
+	// budget is what a chunk may hold while leaving room to close an open fence.
+	budget := func() int {
+		if openTag != "" {
+			return limit - utf16Len(closeFence)
+		}
+		return limit
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		n := utf16Len(line)
+		isFence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		fit := budget()
+		if isFence && openTag != "" {
+			fit = limit // a closing fence uses the room reserved for closing the block
+		}
+		if curLen > 0 && curLen+n > fit {
+			flush()
+		}
+		// A single line that doesn't fit even in an empty chunk is hard-split on rune
+		// boundaries. Fence lines are never split: that would leave stray backticks.
+		for !isFence && curLen+n > fit {
+			head, rest := splitUTF16(line, fit-curLen)
+			if head == "" {
+				break
+			}
+			cur.WriteString(head)
+			curLen += utf16Len(head)
+			flush()
+			line, n = rest, utf16Len(rest)
+		}
+		cur.WriteString(line)
+		curLen += n
+
+		if isFence {
+			if openTag == "" {
+				openTag = strings.TrimSpace(line)
+			} else {
+				openTag = ""
+			}
+		}
+	}
+	if openTag != "" {
+		// Unterminated fence in the source: don't close what the author left open.
+		openTag = ""
+	}
+	flush()
+	return chunks
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+
+func utf16RuneLen(r rune) int {
+	if r <= 0xFFFF {
+		return 1
+	}
+	return 2
+}
+
+// splitUTF16 returns the longest prefix of s within max UTF-16 code units, cut on a
+// rune boundary, and the remainder. It does not cut between a backslash and the
+// character it escapes.
+func splitUTF16(s string, max int) (string, string) {
+	n := 0
+	for i, r := range s {
+		l := utf16RuneLen(r)
+		if n+l > max {
+			if i > 1 && s[i-1] == '\\' {
+				i--
+			}
+			return s[:i], s[i:]
+		}
+		n += l
+	}
+	return s, ""
+}
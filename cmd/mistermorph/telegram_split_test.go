@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitTelegramMessage_Short(t *testing.T) {
+	if got := splitTelegramMessage("  hello  ", 100); len(got) != 1 || got[0] != "hello" {
+		t.Fatalf("got %q", got)
+	}
+	if got := splitTelegramMessage("   ", 100); got != nil {
+		t.Fatalf("blank: got %q", got)
+	}
+}
+
+func TestSplitTelegramMessage_CodeBlockAcrossChunks(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Here is the code:\n```go\n")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&b, "fmt.Println(%d) // line_%d\n", i, i)
+	}
+	b.WriteString("```\nDone.")
+	text := b.String()
+
+	const limit = 200
+	chunks := splitTelegramMessage(text, limit)
+	if len(chunks) < 3 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	var lines []string
+	for i, c := range chunks {
+		if n := utf16Len(c); n > limit {
+			t.Fatalf("chunk %d has %d units > %d", i, n, limit)
+		}
+		if strings.Count(c, "```")%2 != 0 {
+			t.Fatalf("chunk %d has unbalanced fences:\n%s", i, c)
+		}
+		if i > 0 && i < len(chunks)-1 && !strings.HasPrefix(c, "```go\n") {
+			t.Fatalf("chunk %d does not re-open the fence with its language:\n%s", i, c)
+		}
+		for _, l := range strings.Split(c, "\n") {
+			if !strings.HasPrefix(l, "```") {
+				lines = append(lines, l)
+			}
+		}
+	}
+	// Content lines survive in order.
+	var want []string
+	for _, l := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(l, "```") {
+			want = append(want, l)
+		}
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("content changed:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+	if !strings.HasSuffix(chunks[len(chunks)-1], "Done.") {
+		t.Fatalf("last chunk = %q", chunks[len(chunks)-1])
+	}
+}
+
+func TestSplitTelegramMessage_Unicode(t *testing.T) {
+	// One long line of CJK and emoji (emoji are 2 UTF-16 units) forces hard splits.
+	text := strings.Repeat("你好世界😀", 100)
+	const limit = 51
+	chunks := splitTelegramMessage(text, limit)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if !utf8.ValidString(c) {
+			t.Fatalf("chunk %d is not valid UTF-8", i)
+		}
+		if n := utf16Len(c); n > limit {
+			t.Fatalf("chunk %d has %d units > %d", i, n, limit)
+		}
+	}
+	if strings.Join(chunks, "") != text {
+		t.Fatalf("chunks do not reassemble the message")
+	}
+}
+
+func TestSplitTelegramMessage_ClosingFenceAtLimit(t *testing.T) {
+	const limit = 40
+	for bodyLen := 20; bodyLen <= 28; bodyLen++ {
+		body := strings.Repeat("x", bodyLen)
+		text := "intro\n```go\n" + body + "\n```\nafter"
+		chunks := splitTelegramMessage(text, limit)
+		for i, c := range chunks {
+			if n := utf16Len(c); n > limit {
+				t.Fatalf("body %d: chunk %d has %d units > %d", bodyLen, i, n, limit)
+			}
+			if strings.Count(c, "```")%2 != 0 {
+				t.Fatalf("body %d: chunk %d has unbalanced fences: %q", bodyLen, i, chunks)
+			}
+			for _, l := range strings.Split(c, "\n") {
+				if strings.Contains(l, "`") && !strings.HasPrefix(l, "```") {
+					t.Fatalf("body %d: chunk %d has a split fence: %q", bodyLen, i, chunks)
+				}
+			}
+		}
+		last := chunks[len(chunks)-1]
+		if last != "after" && !strings.HasSuffix(last, "```\nafter") {
+			t.Fatalf("body %d: trailing prose ended up inside a code block: %q", bodyLen, chunks)
+		}
+	}
+}
+
+func TestSendMessageChunked_MeasuresEscapedText(t *testing.T) {
+	for name, text := range map[string]string{
+		"lines":       strings.Repeat("some_snake_case_identifier_with_parts\n", 300),
+		"single_line": strings.Repeat("a_", 5000),
+	} {
+		t.Run(name, func(t *testing.T) {
+			api, calls := newFakeTelegramAPI(t)
+			if err := api.sendMessageChunked(context.Background(), 1, text); err != nil {
+				t.Fatalf("sendMessageChunked: %v", err)
+			}
+			var joined strings.Builder
+			got := calls()
+			if len(got) < 2 {
+				t.Fatalf("expected several chunks, got %d", len(got))
+			}
+			for i, c := range got {
+				sent, _ := c.Body["text"].(string)
+				if n := utf16Len(sent); n > telegramChunkLimit {
+					t.Fatalf("chunk %d is %d units after escaping, limit %d", i, n, telegramChunkLimit)
+				}
+				if strings.HasSuffix(sent, `\`) {
+					t.Fatalf("chunk %d ends with a dangling escape", i)
+				}
+				joined.WriteString(sent)
+			}
+			want := strings.ReplaceAll(strings.ReplaceAll(text, "\n", ""), "_", `\_`)
+			if strings.ReplaceAll(joined.String(), "\n", "") != want {
+				t.Fatalf("chunks do not reassemble into the escaped text")
+			}
+		})
+	}
+}
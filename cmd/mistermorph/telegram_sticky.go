@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const telegramStickySkillsFile = "telegram_sticky_skills.json"
+
+// telegramStickyStore persists the per-chat sticky skills under telegram.state_dir
+// so a restart doesn't change which skills are active mid-conversation.
+// A nil store is valid and persists nothing.
+type telegramStickyStore struct {
+	path string
+	cap  int
+}
+
+func newTelegramStickyStore(stateDir string, capN int) *telegramStickyStore {
+	if stateDir == "" {
+		return nil
+	}
+	return &telegramStickyStore{path: filepath.Join(stateDir, telegramStickySkillsFile), cap: capN}
+}
+
+// Load returns the persisted sticky skills, each list de-duplicated and capped.
+// A missing file yields an empty map.
+func (s *telegramStickyStore) Load() (map[int64][]string, error) {
+	out := make(map[int64][]string)
+	if s == nil {
+		return out, nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return out, nil
+	}
+	if err != nil {
+		return out, err
+	}
+	var raw map[string][]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return out, fmt.Errorf("parse %s: %w", s.path, err)
+	}
+	for k, skills := range raw {
+		chatID, err := strconv.ParseInt(k, 10, 64)
+		if err != nil {
+			continue
+		}
+		if capped := capUniqueStrings(skills, s.cap); len(capped) > 0 {
+			out[chatID] = capped
+		}
+	}
+	return out, nil
+}
+
+// Save atomically replaces the persisted sticky skills with m.
+func (s *telegramStickyStore) Save(m map[int64][]string) error {
+	if s == nil {
+		return nil
+	}
+	raw := make(map[string][]string, len(m))
+	for chatID, skills := range m {
+		if len(skills) > 0 {
+			raw[strconv.FormatInt(chatID, 10)] = skills
+		}
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
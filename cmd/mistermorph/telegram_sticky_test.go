@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTelegramStickyStore_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	before := newTelegramStickyStore(dir, 2)
+	if err := before.Save(map[int64][]string{
+		42:   {"weather", "calendar"},
+		-100: {"notes"},
+		7:    nil,
+	}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	after := newTelegramStickyStore(dir, 2)
+	got, err := after.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := map[int64][]string{42: {"weather", "calendar"}, -100: {"notes"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load = %v, want %v", got, want)
+	}
+}
+
+func TestTelegramStickyStore_LoadAppliesCap(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, telegramStickySkillsFile)
+	if err := os.WriteFile(path, []byte(`{"1":["a","A","b","c"],"not-a-chat":["x"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := newTelegramStickyStore(dir, 2).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := map[int64][]string{1: {"a", "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load = %v, want %v", got, want)
+	}
+}
+
+func TestTelegramStickyStore_MissingAndDisabled(t *testing.T) {
+	got, err := newTelegramStickyStore(t.TempDir(), 3).Load()
+	if err != nil || got == nil || len(got) != 0 {
+		t.Fatalf("missing file: got %v, %v", got, err)
+	}
+
+	disabled := newTelegramStickyStore("", 3)
+	if disabled != nil {
+		t.Fatalf("expected nil store for empty state dir")
+	}
+	if err := disabled.Save(map[int64][]string{1: {"a"}}); err != nil {
+		t.Fatalf("Save on nil store: %v", err)
+	}
+	if got, err := disabled.Load(); err != nil || got == nil || len(got) != 0 {
+		t.Fatalf("Load on nil store: got %v, %v", got, err)
+	}
+}
@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/quailyquaily/mistermorph/llm"
 )
 
 func TestTelegramWorkerIdleCleanup(t *testing.T) {
@@ -112,3 +117,229 @@ func TestTelegramWorkerConcurrentEnqueueCancel(t *testing.T) {
 		wg.Wait()
 	}
 }
+
+func TestCollectTelegramConversationStats(t *testing.T) {
+	history := map[int64][]llm.Message{}
+	sticky := map[int64][]string{}
+	workers := map[int64]*telegramChatWorker{}
+
+	if got := collectTelegramConversationStats(history, sticky, workers); got != (telegramConversationStats{}) {
+		t.Fatalf("empty stats = %+v", got)
+	}
+
+	history[1] = []llm.Message{{Role: "user", Content: "a"}, {Role: "assistant", Content: "b"}}
+	history[2] = []llm.Message{{Role: "user", Content: "c"}}
+	sticky[2] = []string{"skill-a", "skill-b"}
+	workers[1] = &telegramChatWorker{}
+	workers[3] = &telegramChatWorker{}
+
+	got := collectTelegramConversationStats(history, sticky, workers)
+	want := telegramConversationStats{Conversations: 3, Workers: 2, HistoryItems: 3, StickySkills: 2}
+	if got != want {
+		t.Fatalf("stats = %+v, want %+v", got, want)
+	}
+
+	// /reset and idle cleanup remove entries; counts must follow.
+	delete(history, 2)
+	delete(sticky, 2)
+	delete(workers, 3)
+	got = collectTelegramConversationStats(history, sticky, workers)
+	want = telegramConversationStats{Conversations: 1, Workers: 1, HistoryItems: 2}
+	if got != want {
+		t.Fatalf("stats after removal = %+v, want %+v", got, want)
+	}
+}
+
+func TestResetTelegramChat_OnlyThatChat(t *testing.T) {
+	history := map[int64][]llm.Message{
+		1: {{Role: "user", Content: "a"}},
+		2: {{Role: "user", Content: "b"}},
+	}
+	sticky := map[int64][]string{1: {"skill-a"}, 2: {"skill-b"}}
+	w1 := &telegramChatWorker{Version: 4}
+	w2 := &telegramChatWorker{Version: 7}
+
+	resetTelegramChat(1, history, sticky, w1)
+
+	if _, ok := history[1]; ok {
+		t.Fatalf("history for chat 1 not cleared: %+v", history)
+	}
+	if _, ok := sticky[1]; ok {
+		t.Fatalf("sticky skills for chat 1 not cleared: %+v", sticky)
+	}
+	if w1.Version != 5 {
+		t.Fatalf("chat 1 version = %d, want 5", w1.Version)
+	}
+	if len(history[2]) != 1 || len(sticky[2]) != 1 || w2.Version != 7 {
+		t.Fatalf("chat 2 state changed: history=%+v sticky=%+v version=%d", history[2], sticky[2], w2.Version)
+	}
+
+	// A chat without a worker is still cleared.
+	resetTelegramChat(2, history, sticky, nil)
+	if len(history) != 0 || len(sticky) != 0 {
+		t.Fatalf("maps not empty after resetting chat 2: history=%+v sticky=%+v", history, sticky)
+	}
+}
+
+func TestTelegramCommandAddressed(t *testing.T) {
+	const botID = 42
+	bot := &telegramUser{ID: botID, Username: "MorphBot"}
+	cases := []struct {
+		name    string
+		cmdWord string
+		msg     *telegramMessage
+		want    bool
+	}{
+		{name: "bare", cmdWord: "/reset", msg: &telegramMessage{Text: "/reset"}, want: false},
+		{name: "suffix_this_bot", cmdWord: "/reset@morphbot", msg: &telegramMessage{Text: "/reset@morphbot"}, want: true},
+		{name: "suffix_other_bot", cmdWord: "/reset@OtherBot", msg: &telegramMessage{Text: "/reset@OtherBot @MorphBot"}, want: false},
+		{name: "reply_to_bot", cmdWord: "/reset", msg: &telegramMessage{Text: "/reset", ReplyTo: &telegramMessage{From: bot}}, want: true},
+		{name: "reply_to_other", cmdWord: "/reset", msg: &telegramMessage{Text: "/reset", ReplyTo: &telegramMessage{From: &telegramUser{ID: 7}}}, want: false},
+		{name: "mention", cmdWord: "/reset", msg: &telegramMessage{Text: "/reset @MorphBot"}, want: true},
+		{name: "text_mention", cmdWord: "/reset", msg: &telegramMessage{Text: "/reset Morph", Entities: []telegramEntity{{Type: "text_mention", Offset: 7, Length: 5, User: bot}}}, want: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := telegramCommandAddressed(tc.msg, tc.cmdWord, "MorphBot", botID); got != tc.want {
+				t.Fatalf("telegramCommandAddressed(%q) = %v, want %v", tc.msg.Text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTelegramResetCommand(t *testing.T) {
+	cases := []struct {
+		raw     string
+		want    string
+		wantErr string
+	}{
+		{raw: "", want: ""},
+		{raw: " /Reset ", want: "/reset"},
+		{raw: "/clear@MorphBot", want: "/clear"},
+		{raw: "reset", wantErr: "must start with '/'"},
+		{raw: "/help", wantErr: "built-in /help"},
+		{raw: "/ASK", wantErr: "built-in /ask"},
+		{raw: "/mem@MorphBot", wantErr: "built-in /mem"},
+	}
+	for _, tc := range cases {
+		got, err := telegramResetCommand(tc.raw)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("telegramResetCommand(%q) err = %v, want %q", tc.raw, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Fatalf("telegramResetCommand(%q) = %q, %v, want %q", tc.raw, got, err, tc.want)
+		}
+	}
+}
+
+type capturingLLMClient struct {
+	reply string
+	req   llm.Request
+}
+
+func (c *capturingLLMClient) Chat(_ context.Context, req llm.Request) (llm.Result, error) {
+	c.req = req
+	return llm.Result{Text: c.reply}, nil
+}
+
+func TestAddressingDecisionViaLLM_CustomPrompt(t *testing.T) {
+	const custom = "Messages about deployments are always addressed to the bot."
+	cases := []struct {
+		name        string
+		prompt      addressingLLMPrompt
+		wantDefault bool
+		wantCustom  bool
+	}{
+		{name: "default", prompt: addressingLLMPrompt{}, wantDefault: true},
+		{name: "append", prompt: addressingLLMPrompt{Guidance: custom}, wantDefault: true, wantCustom: true},
+		{name: "replace", prompt: addressingLLMPrompt{Guidance: custom, Replace: true}, wantCustom: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &capturingLLMClient{reply: `{"addressed":true,"confidence":0.9,"task_text":"deploy web","reason":"custom rule"}`}
+			dec, ok, err := addressingDecisionViaLLM(context.Background(), client, "m", tc.prompt, "morphbot", []string{"morph"}, "morph deploy web")
+			if err != nil || !ok {
+				t.Fatalf("ok=%v err=%v", ok, err)
+			}
+			if !dec.Addressed || dec.TaskText != "deploy web" || dec.Confidence != 0.9 {
+				t.Fatalf("decision = %+v", dec)
+			}
+			sys := client.req.Messages[0].Content
+			if got := strings.Contains(sys, defaultAddressingLLMGuidance); got != tc.wantDefault {
+				t.Fatalf("default guidance present = %v, want %v", got, tc.wantDefault)
+			}
+			if got := strings.Contains(sys, custom); got != tc.wantCustom {
+				t.Fatalf("custom guidance present = %v, want %v", got, tc.wantCustom)
+			}
+			if !strings.HasSuffix(sys, addressingLLMOutputRules) {
+				t.Fatalf("output rules must always be enforced, got %q", sys)
+			}
+		})
+	}
+}
+
+func TestTelegramAddressingAction(t *testing.T) {
+	const replyMin, ackMin = 0.55, 0.3
+	addressed := func(c float64) telegramAddressingLLMDecision {
+		return telegramAddressingLLMDecision{Addressed: true, Confidence: c}
+	}
+	cases := []struct {
+		name  string
+		mode  string
+		llmOK bool
+		dec   telegramAddressingLLMDecision
+		ack   float64
+		want  string
+	}{
+		{name: "soft_high_replies", mode: "soft", llmOK: true, dec: addressed(0.9), ack: ackMin, want: telegramAddressingReply},
+		{name: "soft_at_reply_threshold", mode: "soft", llmOK: true, dec: addressed(replyMin), ack: ackMin, want: telegramAddressingReply},
+		{name: "soft_mid_band_reacts", mode: "soft", llmOK: true, dec: addressed(0.4), ack: ackMin, want: telegramAddressingReact},
+		{name: "soft_at_ack_threshold", mode: "soft", llmOK: true, dec: addressed(ackMin), ack: ackMin, want: telegramAddressingReact},
+		{name: "soft_low_ignored", mode: "soft", llmOK: true, dec: addressed(0.1), ack: ackMin, want: telegramAddressingIgnore},
+		{name: "soft_not_addressed", mode: "soft", llmOK: true, dec: telegramAddressingLLMDecision{Confidence: 0.9}, ack: ackMin, want: telegramAddressingIgnore},
+		{name: "soft_llm_failed", mode: "soft", llmOK: false, dec: addressed(0.4), ack: ackMin, want: telegramAddressingIgnore},
+		{name: "soft_band_disabled", mode: "soft", llmOK: true, dec: addressed(0.4), ack: 0, want: telegramAddressingIgnore},
+		{name: "smart_mid_band_ignored", mode: "smart", llmOK: true, dec: addressed(0.4), ack: ackMin, want: telegramAddressingIgnore},
+		{name: "smart_high_replies", mode: "smart", llmOK: true, dec: addressed(0.9), ack: ackMin, want: telegramAddressingReply},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := telegramAddressingAction(tc.mode, tc.llmOK, tc.dec, replyMin, tc.ack); got != tc.want {
+				t.Fatalf("action = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTelegramSetMessageReaction(t *testing.T) {
+	api, calls := newFakeTelegramAPI(t)
+	if err := api.setMessageReaction(context.Background(), -100, 42, "👀"); err != nil {
+		t.Fatalf("setMessageReaction: %v", err)
+	}
+	got := calls()
+	if len(got) != 1 || got[0].Method != "setMessageReaction" {
+		t.Fatalf("calls = %+v", got)
+	}
+	body := got[0].Body
+	reaction, _ := body["reaction"].([]any)
+	if body["chat_id"] != float64(-100) || body["message_id"] != float64(42) || len(reaction) != 1 {
+		t.Fatalf("body = %#v", body)
+	}
+	if r, _ := reaction[0].(map[string]any); r["type"] != "emoji" || r["emoji"] != "👀" {
+		t.Fatalf("reaction = %#v", reaction[0])
+	}
+}
+
+func TestTelegramSetMessageReaction_NotOK(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":false,"description":"Bad Request: REACTION_INVALID"}`))
+	}))
+	defer srv.Close()
+	api := newTelegramAPI(srv.Client(), srv.URL, "TOKEN")
+	if err := api.setMessageReaction(context.Background(), -100, 42, "🦄"); err == nil {
+		t.Fatalf("expected error for ok=false response")
+	}
+}
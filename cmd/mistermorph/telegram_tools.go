@@ -0,0 +1,592 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// postJSON calls a Bot API method with a JSON body and checks the `ok` flag in the response.
+func (api *telegramAPI) postJSON(ctx context.Context, method string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/bot%s/%s", api.baseURL, api.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := api.http.Do(req)
+	if err != nil {
+		return err
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("telegram http %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	var ok telegramOKResponse
+	_ = json.Unmarshal(raw, &ok)
+	if !ok.OK {
+		return fmt.Errorf("telegram %s: ok=false", method)
+	}
+	return nil
+}
+
+// telegramChatIDParam reads an integer chat id from tool params, falling back to def.
+func telegramChatIDParam(params map[string]any, key string, def int64) int64 {
+	v, ok := params[key]
+	if !ok {
+		return def
+	}
+	switch x := v.(type) {
+	case int64:
+		return x
+	case int:
+		return int64(x)
+	case float64:
+		return int64(x)
+	}
+	return def
+}
+
+func telegramFloatParam(params map[string]any, key string) (float64, bool) {
+	switch x := params[key].(type) {
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+type telegramSendLocationRequest struct {
+	ChatID     int64   `json:"chat_id"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	LivePeriod int     `json:"live_period,omitempty"`
+}
+
+func (api *telegramAPI) sendLocation(ctx context.Context, chatID int64, lat, lon float64, livePeriod int) error {
+	return api.postJSON(ctx, "sendLocation", telegramSendLocationRequest{
+		ChatID:     chatID,
+		Latitude:   lat,
+		Longitude:  lon,
+		LivePeriod: livePeriod,
+	})
+}
+
+type telegramSendLocationTool struct {
+	api        *telegramAPI
+	defaultTo  int64
+	enabled    bool
+	allowedIDs map[int64]bool
+}
+
+func newTelegramSendLocationTool(api *telegramAPI, defaultChatID int64, allowedIDs map[int64]bool) *telegramSendLocationTool {
+	return &telegramSendLocationTool{
+		api:        api,
+		defaultTo:  defaultChatID,
+		enabled:    true,
+		allowedIDs: allowedIDs,
+	}
+}
+
+func (t *telegramSendLocationTool) Name() string { return "telegram_send_location" }
+
+func (t *telegramSendLocationTool) Description() string {
+	return "Sends a map location (latitude/longitude) to a Telegram chat, optionally as a live location. Use chat_id when not running in an active chat context."
+}
+
+func (t *telegramSendLocationTool) ParameterSchema() string {
+	s := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"chat_id": map[string]any{
+				"type":        "integer",
+				"description": "Target Telegram chat_id. Optional in interactive chat context; required for scheduled runs unless default chat_id is set.",
+			},
+			"latitude": map[string]any{
+				"type":        "number",
+				"description": "Latitude in degrees, -90..90.",
+			},
+			"longitude": map[string]any{
+				"type":        "number",
+				"description": "Longitude in degrees, -180..180.",
+			},
+			"live_period": map[string]any{
+				"type":        "integer",
+				"description": "Optional: seconds the location stays live (60..86400). Omit for a static location.",
+			},
+		},
+		"required": []string{"latitude", "longitude"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *telegramSendLocationTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	if !t.enabled || t.api == nil {
+		return "", fmt.Errorf("telegram_send_location is disabled")
+	}
+
+	chatID := telegramChatIDParam(params, "chat_id", t.defaultTo)
+	if chatID == 0 {
+		return "", fmt.Errorf("missing required param: chat_id")
+	}
+	if len(t.allowedIDs) > 0 && !t.allowedIDs[chatID] {
+		return "", fmt.Errorf("unauthorized chat_id: %d", chatID)
+	}
+
+	lat, ok := telegramFloatParam(params, "latitude")
+	if !ok {
+		return "", fmt.Errorf("missing required param: latitude")
+	}
+	lon, ok := telegramFloatParam(params, "longitude")
+	if !ok {
+		return "", fmt.Errorf("missing required param: longitude")
+	}
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return "", fmt.Errorf("latitude out of range [-90,90]: %v", lat)
+	}
+	if math.IsNaN(lon) || lon < -180 || lon > 180 {
+		return "", fmt.Errorf("longitude out of range [-180,180]: %v", lon)
+	}
+
+	livePeriod := 0
+	if v, ok := telegramFloatParam(params, "live_period"); ok && v != 0 {
+		if v < 60 || v > 86400 {
+			return "", fmt.Errorf("live_period must be between 60 and 86400 seconds")
+		}
+		livePeriod = int(v)
+	}
+
+	if err := t.api.sendLocation(ctx, chatID, lat, lon, livePeriod); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sent location: %.6f,%.6f", lat, lon), nil
+}
+
+type telegramPollOption struct {
+	Text string `json:"text"`
+}
+
+type telegramSendPollRequest struct {
+	ChatID          int64                `json:"chat_id"`
+	Question        string               `json:"question"`
+	Options         []telegramPollOption `json:"options"`
+	IsAnonymous     *bool                `json:"is_anonymous,omitempty"`
+	Type            string               `json:"type,omitempty"`
+	CorrectOptionID *int                 `json:"correct_option_id,omitempty"`
+}
+
+func (api *telegramAPI) sendPoll(ctx context.Context, req telegramSendPollRequest) error {
+	return api.postJSON(ctx, "sendPoll", req)
+}
+
+type telegramSendPollTool struct {
+	api        *telegramAPI
+	defaultTo  int64
+	enabled    bool
+	allowedIDs map[int64]bool
+}
+
+func newTelegramSendPollTool(api *telegramAPI, defaultChatID int64, allowedIDs map[int64]bool) *telegramSendPollTool {
+	return &telegramSendPollTool{
+		api:        api,
+		defaultTo:  defaultChatID,
+		enabled:    true,
+		allowedIDs: allowedIDs,
+	}
+}
+
+func (t *telegramSendPollTool) Name() string { return "telegram_send_poll" }
+
+func (t *telegramSendPollTool) Description() string {
+	return "Sends a poll or quiz to a Telegram chat. Provide a question and 2-10 options; for type=quiz also provide correct_option_id. Use chat_id when not running in an active chat context."
+}
+
+func (t *telegramSendPollTool) ParameterSchema() string {
+	s := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"chat_id": map[string]any{
+				"type":        "integer",
+				"description": "Target Telegram chat_id. Optional in interactive chat context; required for scheduled runs unless default chat_id is set.",
+			},
+			"question": map[string]any{
+				"type":        "string",
+				"description": "Poll question (1-300 characters).",
+			},
+			"options": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Answer options (2-10 items, each 1-100 characters).",
+			},
+			"is_anonymous": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: whether votes are anonymous (Telegram default: true).",
+			},
+			"type": map[string]any{
+				"type":        "string",
+				"enum":        []string{"regular", "quiz"},
+				"description": "Poll type (default: regular).",
+			},
+			"correct_option_id": map[string]any{
+				"type":        "integer",
+				"description": "Required for quiz: 0-based index of the correct option.",
+			},
+		},
+		"required": []string{"question", "options"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *telegramSendPollTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	if !t.enabled || t.api == nil {
+		return "", fmt.Errorf("telegram_send_poll is disabled")
+	}
+
+	chatID := telegramChatIDParam(params, "chat_id", t.defaultTo)
+	if chatID == 0 {
+		return "", fmt.Errorf("missing required param: chat_id")
+	}
+	if len(t.allowedIDs) > 0 && !t.allowedIDs[chatID] {
+		return "", fmt.Errorf("unauthorized chat_id: %d", chatID)
+	}
+
+	question, _ := params["question"].(string)
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return "", fmt.Errorf("missing required param: question")
+	}
+	if len([]rune(question)) > 300 {
+		return "", fmt.Errorf("question is too long (max 300 characters)")
+	}
+
+	var options []telegramPollOption
+	switch raw := params["options"].(type) {
+	case []any:
+		for _, v := range raw {
+			s, _ := v.(string)
+			options = append(options, telegramPollOption{Text: strings.TrimSpace(s)})
+		}
+	case []string:
+		for _, s := range raw {
+			options = append(options, telegramPollOption{Text: strings.TrimSpace(s)})
+		}
+	}
+	if len(options) < 2 || len(options) > 10 {
+		return "", fmt.Errorf("options must contain 2-10 items (got %d)", len(options))
+	}
+	for i, opt := range options {
+		if opt.Text == "" || len([]rune(opt.Text)) > 100 {
+			return "", fmt.Errorf("options[%d] must be 1-100 characters", i)
+		}
+	}
+
+	req := telegramSendPollRequest{ChatID: chatID, Question: question, Options: options}
+	if v, ok := params["is_anonymous"].(bool); ok {
+		req.IsAnonymous = &v
+	}
+
+	pollType, _ := params["type"].(string)
+	pollType = strings.ToLower(strings.TrimSpace(pollType))
+	switch pollType {
+	case "", "regular":
+		req.Type = "regular"
+	case "quiz":
+		req.Type = "quiz"
+		idx, ok := telegramFloatParam(params, "correct_option_id")
+		if !ok {
+			return "", fmt.Errorf("quiz requires correct_option_id")
+		}
+		if idx != math.Trunc(idx) || idx < 0 || int(idx) >= len(options) {
+			return "", fmt.Errorf("correct_option_id out of range [0,%d]: %v", len(options)-1, idx)
+		}
+		n := int(idx)
+		req.CorrectOptionID = &n
+	default:
+		return "", fmt.Errorf("invalid type %q (use regular or quiz)", pollType)
+	}
+
+	if err := t.api.sendPoll(ctx, req); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sent %s poll: %s", req.Type, question), nil
+}
+
+type telegramForwardMessageRequest struct {
+	ChatID     int64 `json:"chat_id"`
+	FromChatID int64 `json:"from_chat_id"`
+	MessageID  int64 `json:"message_id"`
+}
+
+func (api *telegramAPI) forwardMessage(ctx context.Context, toChatID, fromChatID, messageID int64) error {
+	return api.postJSON(ctx, "forwardMessage", telegramForwardMessageRequest{
+		ChatID:     toChatID,
+		FromChatID: fromChatID,
+		MessageID:  messageID,
+	})
+}
+
+type telegramForwardMessageTool struct {
+	api        *telegramAPI
+	defaultTo  int64
+	enabled    bool
+	allowedIDs map[int64]bool
+}
+
+func newTelegramForwardMessageTool(api *telegramAPI, defaultChatID int64, allowedIDs map[int64]bool) *telegramForwardMessageTool {
+	return &telegramForwardMessageTool{
+		api:        api,
+		defaultTo:  defaultChatID,
+		enabled:    true,
+		allowedIDs: allowedIDs,
+	}
+}
+
+func (t *telegramForwardMessageTool) Name() string { return "telegram_forward_message" }
+
+func (t *telegramForwardMessageTool) Description() string {
+	return "Forwards an existing Telegram message (from_chat_id + message_id) to a chat. Use this to relay a message as-is instead of re-authoring it. Use chat_id when not running in an active chat context."
+}
+
+func (t *telegramForwardMessageTool) ParameterSchema() string {
+	s := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"chat_id": map[string]any{
+				"type":        "integer",
+				"description": "Destination Telegram chat_id. Optional in interactive chat context; required for scheduled runs unless default chat_id is set.",
+			},
+			"from_chat_id": map[string]any{
+				"type":        "integer",
+				"description": "Chat_id where the original message was sent.",
+			},
+			"message_id": map[string]any{
+				"type":        "integer",
+				"description": "Message id in from_chat_id to forward.",
+			},
+		},
+		"required": []string{"from_chat_id", "message_id"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *telegramForwardMessageTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	if !t.enabled || t.api == nil {
+		return "", fmt.Errorf("telegram_forward_message is disabled")
+	}
+
+	toChatID := telegramChatIDParam(params, "chat_id", t.defaultTo)
+	if toChatID == 0 {
+		return "", fmt.Errorf("missing required param: chat_id")
+	}
+	fromChatID := telegramChatIDParam(params, "from_chat_id", 0)
+	if fromChatID == 0 {
+		return "", fmt.Errorf("missing required param: from_chat_id")
+	}
+	messageID := telegramChatIDParam(params, "message_id", 0)
+	if messageID <= 0 {
+		return "", fmt.Errorf("missing required param: message_id")
+	}
+	if len(t.allowedIDs) > 0 {
+		if !t.allowedIDs[fromChatID] {
+			return "", fmt.Errorf("unauthorized from_chat_id: %d", fromChatID)
+		}
+		if !t.allowedIDs[toChatID] {
+			return "", fmt.Errorf("unauthorized chat_id: %d", toChatID)
+		}
+	}
+
+	if err := t.api.forwardMessage(ctx, toChatID, fromChatID, messageID); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("forwarded message %d from %d to %d", messageID, fromChatID, toChatID), nil
+}
+
+// telegramAudioMeta is the optional track metadata Telegram shows in its audio player.
+type telegramAudioMeta struct {
+	Performer string
+	Title     string
+	Duration  int // seconds
+}
+
+func (api *telegramAPI) sendAudio(ctx context.Context, chatID int64, filePath string, filename string, caption string, meta telegramAudioMeta) error {
+	fields := map[string]string{
+		"caption":   caption,
+		"performer": meta.Performer,
+		"title":     meta.Title,
+	}
+	if meta.Duration > 0 {
+		fields["duration"] = strconv.Itoa(meta.Duration)
+	}
+	return api.sendMultipartFile(ctx, "sendAudio", "audio", chatID, filePath, filename, "audio.mp3", fields)
+}
+
+type telegramSendAudioTool struct {
+	api        *telegramAPI
+	defaultTo  int64
+	cacheDir   string
+	maxBytes   int64
+	allowedIDs map[int64]bool
+}
+
+func newTelegramSendAudioTool(api *telegramAPI, defaultChatID int64, cacheDir string, maxBytes int64, allowedIDs map[int64]bool) *telegramSendAudioTool {
+	if maxBytes <= 0 {
+		maxBytes = 20 * 1024 * 1024
+	}
+	return &telegramSendAudioTool{
+		api:        api,
+		defaultTo:  defaultChatID,
+		cacheDir:   strings.TrimSpace(cacheDir),
+		maxBytes:   maxBytes,
+		allowedIDs: allowedIDs,
+	}
+}
+
+func (t *telegramSendAudioTool) Name() string { return "telegram_send_audio" }
+
+func (t *telegramSendAudioTool) Description() string {
+	return "Sends a local audio file (e.g. .mp3/.m4a under file_cache_dir) as a playable Telegram audio track with optional performer/title/duration. Use telegram_send_voice for voice notes. Use chat_id when not running in an active chat context."
+}
+
+func (t *telegramSendAudioTool) ParameterSchema() string {
+	s := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"chat_id": map[string]any{
+				"type":        "integer",
+				"description": "Target Telegram chat_id. Optional in interactive chat context; required for scheduled runs unless default chat_id is set.",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Path to a local audio file under file_cache_dir (absolute or relative to that directory).",
+			},
+			"performer": map[string]any{
+				"type":        "string",
+				"description": "Optional performer shown in the player.",
+			},
+			"title": map[string]any{
+				"type":        "string",
+				"description": "Optional track title shown in the player.",
+			},
+			"duration": map[string]any{
+				"type":        "integer",
+				"description": "Optional duration in seconds.",
+			},
+			"filename": map[string]any{
+				"type":        "string",
+				"description": "Optional filename shown to the user (default: basename of path).",
+			},
+			"caption": map[string]any{
+				"type":        "string",
+				"description": "Optional caption text.",
+			},
+		},
+		"required": []string{"path"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *telegramSendAudioTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	if t.api == nil {
+		return "", fmt.Errorf("telegram_send_audio is disabled")
+	}
+	chatID := telegramChatIDParam(params, "chat_id", t.defaultTo)
+	if chatID == 0 {
+		return "", fmt.Errorf("missing required param: chat_id")
+	}
+	if len(t.allowedIDs) > 0 && !t.allowedIDs[chatID] {
+		return "", fmt.Errorf("unauthorized chat_id: %d", chatID)
+	}
+
+	rawPath, _ := params["path"].(string)
+	rawPath = strings.TrimSpace(rawPath)
+	if rawPath == "" {
+		return "", fmt.Errorf("missing required param: path")
+	}
+	cacheDir := strings.TrimSpace(t.cacheDir)
+	if cacheDir == "" {
+		return "", fmt.Errorf("file cache dir is not configured")
+	}
+	p := rawPath
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(cacheDir, p)
+	}
+	p = filepath.Clean(p)
+
+	cacheAbs, err := filepath.Abs(cacheDir)
+	if err != nil {
+		return "", err
+	}
+	pathAbs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(cacheAbs, pathAbs)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) || rel == ".." {
+		return "", fmt.Errorf("refusing to send file outside file_cache_dir: %s", pathAbs)
+	}
+
+	st, err := os.Stat(pathAbs)
+	if err != nil {
+		return "", err
+	}
+	if st.IsDir() {
+		return "", fmt.Errorf("path is a directory: %s", pathAbs)
+	}
+	if t.maxBytes > 0 && st.Size() > t.maxBytes {
+		return "", fmt.Errorf("file too large to send (>%d bytes): %s", t.maxBytes, pathAbs)
+	}
+
+	var meta telegramAudioMeta
+	meta.Performer, _ = params["performer"].(string)
+	meta.Title, _ = params["title"].(string)
+	if d, ok := telegramFloatParam(params, "duration"); ok {
+		if d < 0 {
+			return "", fmt.Errorf("duration must be >= 0")
+		}
+		meta.Duration = int(d)
+	}
+
+	filename, _ := params["filename"].(string)
+	filename = strings.TrimSpace(filename)
+	if filename == "" {
+		filename = filepath.Base(pathAbs)
+	}
+	filename = sanitizeFilename(filename)
+
+	caption, _ := params["caption"].(string)
+
+	// Keep the file out of cache cleanup until delivery finishes.
+	defaultFileCachePins.Pin(pathAbs)
+	defer defaultFileCachePins.Unpin(pathAbs)
+	if err := t.api.sendAudio(ctx, chatID, pathAbs, filename, caption, meta); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sent audio: %s", filename), nil
+}
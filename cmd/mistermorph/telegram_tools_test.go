@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type telegramCall struct {
+	Method string
+	Body   map[string]any
+}
+
+// newFakeTelegramAPI records every Bot API call and answers {"ok":true}.
+func newFakeTelegramAPI(t *testing.T) (*telegramAPI, func() []telegramCall) {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		calls []telegramCall
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(raw, &body)
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		mu.Lock()
+		calls = append(calls, telegramCall{Method: method, Body: body})
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return newTelegramAPI(srv.Client(), srv.URL, "TOKEN"), func() []telegramCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]telegramCall(nil), calls...)
+	}
+}
+
+func TestTelegramSendLocationTool(t *testing.T) {
+	cases := []struct {
+		name    string
+		allowed map[int64]bool
+		params  map[string]any
+		wantErr string
+	}{
+		{name: "valid", params: map[string]any{"latitude": 35.68, "longitude": 139.76, "live_period": float64(120)}},
+		{name: "explicit_chat", allowed: map[int64]bool{7: true}, params: map[string]any{"chat_id": float64(7), "latitude": 0.0, "longitude": 0.0}},
+		{name: "lat_range", params: map[string]any{"latitude": 91.0, "longitude": 0.0}, wantErr: "latitude out of range"},
+		{name: "lon_range", params: map[string]any{"latitude": 0.0, "longitude": -180.5}, wantErr: "longitude out of range"},
+		{name: "live_period_range", params: map[string]any{"latitude": 0.0, "longitude": 0.0, "live_period": float64(10)}, wantErr: "live_period"},
+		{name: "missing_lat", params: map[string]any{"longitude": 0.0}, wantErr: "latitude"},
+		{name: "unauthorized", allowed: map[int64]bool{7: true}, params: map[string]any{"chat_id": float64(8), "latitude": 0.0, "longitude": 0.0}, wantErr: "unauthorized chat_id: 8"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			api, calls := newFakeTelegramAPI(t)
+			tool := newTelegramSendLocationTool(api, 42, tc.allowed)
+			_, err := tool.Execute(context.Background(), tc.params)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tc.wantErr)
+				}
+				if n := len(calls()); n != 0 {
+					t.Fatalf("expected no API calls, got %d", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			got := calls()
+			if len(got) != 1 || got[0].Method != "sendLocation" {
+				t.Fatalf("calls = %+v", got)
+			}
+			wantChat := telegramChatIDParam(tc.params, "chat_id", 42)
+			if got[0].Body["chat_id"] != float64(wantChat) ||
+				got[0].Body["latitude"] != tc.params["latitude"] ||
+				got[0].Body["longitude"] != tc.params["longitude"] {
+				t.Fatalf("payload = %+v", got[0].Body)
+			}
+			if lp, ok := tc.params["live_period"]; ok && got[0].Body["live_period"] != lp {
+				t.Fatalf("live_period = %v, want %v", got[0].Body["live_period"], lp)
+			}
+		})
+	}
+}
+
+func TestTelegramSendPollTool(t *testing.T) {
+	t.Run("regular", func(t *testing.T) {
+		api, calls := newFakeTelegramAPI(t)
+		tool := newTelegramSendPollTool(api, 42, nil)
+		_, err := tool.Execute(context.Background(), map[string]any{
+			"question":     "Lunch?",
+			"options":      []any{"Pizza", " Sushi "},
+			"is_anonymous": false,
+		})
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		got := calls()
+		if len(got) != 1 || got[0].Method != "sendPoll" {
+			t.Fatalf("calls = %+v", got)
+		}
+		b := got[0].Body
+		if b["chat_id"] != float64(42) || b["question"] != "Lunch?" || b["type"] != "regular" || b["is_anonymous"] != false {
+			t.Fatalf("payload = %+v", b)
+		}
+		opts, _ := b["options"].([]any)
+		if len(opts) != 2 || opts[1].(map[string]any)["text"] != "Sushi" {
+			t.Fatalf("options = %+v", b["options"])
+		}
+		if _, ok := b["correct_option_id"]; ok {
+			t.Fatalf("regular poll must not send correct_option_id")
+		}
+	})
+
+	t.Run("quiz", func(t *testing.T) {
+		api, calls := newFakeTelegramAPI(t)
+		tool := newTelegramSendPollTool(api, 42, nil)
+		_, err := tool.Execute(context.Background(), map[string]any{
+			"question":          "2+2?",
+			"options":           []any{"3", "4", "5"},
+			"type":              "quiz",
+			"correct_option_id": float64(0),
+		})
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		b := calls()[0].Body
+		if b["type"] != "quiz" || b["correct_option_id"] != float64(0) {
+			t.Fatalf("payload = %+v", b)
+		}
+		if _, ok := b["is_anonymous"]; ok {
+			t.Fatalf("is_anonymous should be omitted when not provided")
+		}
+	})
+
+	errCases := []struct {
+		name    string
+		params  map[string]any
+		wantErr string
+	}{
+		{name: "one_option", params: map[string]any{"question": "q", "options": []any{"a"}}, wantErr: "2-10 items"},
+		{name: "eleven_options", params: map[string]any{"question": "q", "options": []any{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}}, wantErr: "2-10 items"},
+		{name: "empty_option", params: map[string]any{"question": "q", "options": []any{"a", " "}}, wantErr: "options[1]"},
+		{name: "missing_question", params: map[string]any{"options": []any{"a", "b"}}, wantErr: "question"},
+		{name: "quiz_missing_index", params: map[string]any{"question": "q", "options": []any{"a", "b"}, "type": "quiz"}, wantErr: "correct_option_id"},
+		{name: "quiz_index_range", params: map[string]any{"question": "q", "options": []any{"a", "b"}, "type": "quiz", "correct_option_id": float64(2)}, wantErr: "out of range"},
+		{name: "bad_type", params: map[string]any{"question": "q", "options": []any{"a", "b"}, "type": "survey"}, wantErr: "invalid type"},
+	}
+	for _, tc := range errCases {
+		t.Run(tc.name, func(t *testing.T) {
+			api, calls := newFakeTelegramAPI(t)
+			_, err := newTelegramSendPollTool(api, 42, nil).Execute(context.Background(), tc.params)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+			if n := len(calls()); n != 0 {
+				t.Fatalf("expected no API calls, got %d", n)
+			}
+		})
+	}
+}
+
+func TestTelegramForwardMessageTool(t *testing.T) {
+	allowed := map[int64]bool{1: true, 2: true}
+	cases := []struct {
+		name    string
+		params  map[string]any
+		wantErr string
+	}{
+		{name: "valid", params: map[string]any{"chat_id": float64(2), "from_chat_id": float64(1), "message_id": float64(99)}},
+		{name: "unauthorized_source", params: map[string]any{"chat_id": float64(2), "from_chat_id": float64(3), "message_id": float64(99)}, wantErr: "unauthorized from_chat_id: 3"},
+		{name: "unauthorized_destination", params: map[string]any{"chat_id": float64(3), "from_chat_id": float64(1), "message_id": float64(99)}, wantErr: "unauthorized chat_id: 3"},
+		{name: "missing_message_id", params: map[string]any{"chat_id": float64(2), "from_chat_id": float64(1)}, wantErr: "message_id"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			api, calls := newFakeTelegramAPI(t)
+			_, err := newTelegramForwardMessageTool(api, 0, allowed).Execute(context.Background(), tc.params)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tc.wantErr)
+				}
+				if n := len(calls()); n != 0 {
+					t.Fatalf("expected no API calls, got %d", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			got := calls()
+			if len(got) != 1 || got[0].Method != "forwardMessage" {
+				t.Fatalf("calls = %+v", got)
+			}
+			b := got[0].Body
+			if b["chat_id"] != float64(2) || b["from_chat_id"] != float64(1) || b["message_id"] != float64(99) {
+				t.Fatalf("payload = %+v", b)
+			}
+		})
+	}
+}
+
+func TestVoiceSynthConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		in   voiceSynthConfig
+		want voiceSynthConfig
+	}{
+		{name: "defaults", in: voiceSynthConfig{}, want: voiceSynthConfig{MaxChars: 1200, OpusBitrateKbps: 24, MaxConcurrent: 2}},
+		{name: "custom", in: voiceSynthConfig{MaxChars: 300, OpusBitrateKbps: 64, MaxConcurrent: 4}, want: voiceSynthConfig{MaxChars: 300, OpusBitrateKbps: 64, MaxConcurrent: 4}},
+		{name: "bitrate_low", in: voiceSynthConfig{MaxChars: 10, OpusBitrateKbps: 2}, want: voiceSynthConfig{MaxChars: 10, OpusBitrateKbps: 6, MaxConcurrent: 2}},
+		{name: "bitrate_high", in: voiceSynthConfig{MaxChars: 10, OpusBitrateKbps: 1000}, want: voiceSynthConfig{MaxChars: 10, OpusBitrateKbps: 510, MaxConcurrent: 2}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := normalizeVoiceSynthConfig(tc.in); got != tc.want {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+
+	args := strings.Join(ffmpegOpusArgs("in.wav", "out.ogg", 64), " ")
+	if !strings.Contains(args, "-b:a 64k") || !strings.HasSuffix(args, "out.ogg") {
+		t.Fatalf("ffmpeg args = %q", args)
+	}
+}
+
+func TestSynthGate_BoundsConcurrency(t *testing.T) {
+	const limit = 2
+	g := &synthGate{}
+	var (
+		mu      sync.Mutex
+		active  int
+		maxSeen int
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := g.acquire(context.Background(), limit)
+			if err != nil {
+				t.Errorf("acquire: %v", err)
+				return
+			}
+			mu.Lock()
+			active++
+			if active > maxSeen {
+				maxSeen = active
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+			release()
+			release() // idempotent
+		}()
+	}
+	wg.Wait()
+	if maxSeen > limit || maxSeen == 0 {
+		t.Fatalf("max concurrent = %d, want 1..%d", maxSeen, limit)
+	}
+	if g.active != 0 {
+		t.Fatalf("active = %d after all releases", g.active)
+	}
+}
+
+func TestSynthGate_WaitRespectsContext(t *testing.T) {
+	g := &synthGate{}
+	release, err := g.acquire(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.acquire(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	release()
+	release2, err := g.acquire(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release2()
+}
+
+func TestTruncateVoiceText_RuneBoundary(t *testing.T) {
+	if got := truncateVoiceText("héllo wörld", 4); got != "héll" {
+		t.Fatalf("got %q", got)
+	}
+	if got := truncateVoiceText("日本語のテキスト", 3); got != "日本語" {
+		t.Fatalf("got %q", got)
+	}
+	if got := truncateVoiceText("  short  ", 100); got != "short" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestTelegramSendAudioTool(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		method string
+		fields map[string]string
+		upload string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		method = r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		fields = map[string]string{}
+		for k, v := range r.MultipartForm.Value {
+			fields[k] = v[0]
+		}
+		if fh := r.MultipartForm.File["audio"]; len(fh) == 1 {
+			upload = fh[0].Filename
+		}
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+	api := newTelegramAPI(srv.Client(), srv.URL, "TOKEN")
+
+	cacheDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cacheDir, "song.mp3"), []byte("ID3"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "secret.mp3")
+	if err := os.WriteFile(outside, []byte("ID3"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := newTelegramSendAudioTool(api, 42, cacheDir, 0, nil)
+	out, err := tool.Execute(context.Background(), map[string]any{
+		"path":      "song.mp3",
+		"performer": "The Band",
+		"title":     "Track One",
+		"duration":  float64(185),
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out != "sent audio: song.mp3" {
+		t.Fatalf("out = %q", out)
+	}
+	mu.Lock()
+	if method != "sendAudio" || upload != "song.mp3" {
+		t.Fatalf("method=%q upload=%q", method, upload)
+	}
+	if fields["chat_id"] != "42" || fields["performer"] != "The Band" || fields["title"] != "Track One" || fields["duration"] != "185" {
+		t.Fatalf("fields = %+v", fields)
+	}
+	method = ""
+	mu.Unlock()
+
+	for _, p := range []string{outside, "../secret.mp3"} {
+		if _, err := tool.Execute(context.Background(), map[string]any{"path": p}); err == nil || !strings.Contains(err.Error(), "outside file_cache_dir") {
+			t.Fatalf("path %q: err = %v, want containment rejection", p, err)
+		}
+	}
+	if _, err := newTelegramSendAudioTool(api, 42, cacheDir, 2, nil).Execute(context.Background(), map[string]any{"path": "song.mp3"}); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("err = %v, want size rejection", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if method != "" {
+		t.Fatalf("rejected sends must not call the API, got %q", method)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/viper"
+)
+
+const defaultVoiceLang = "en"
+
+// ttsScript is a non-Latin writing system that identifies a language reliably enough
+// to override the configured default.
+type ttsScript struct {
+	name  string
+	table *unicode.RangeTable
+	lang  string // language used when the default does not use this script
+}
+
+var ttsScripts = []ttsScript{
+	{name: "Kana", table: kanaTable, lang: "ja"},
+	{name: "Han", table: unicode.Han, lang: "zh"},
+	{name: "Hangul", table: unicode.Hangul, lang: "ko"},
+	{name: "Cyrillic", table: unicode.Cyrillic, lang: "ru"},
+	{name: "Arabic", table: unicode.Arabic, lang: "ar"},
+	{name: "Hebrew", table: unicode.Hebrew, lang: "he"},
+	{name: "Greek", table: unicode.Greek, lang: "el"},
+	{name: "Devanagari", table: unicode.Devanagari, lang: "hi"},
+	{name: "Thai", table: unicode.Thai, lang: "th"},
+}
+
+var kanaTable = &unicode.RangeTable{R16: []unicode.Range16{
+	{Lo: 0x3040, Hi: 0x309f, Stride: 1}, // Hiragana
+	{Lo: 0x30a0, Hi: 0x30ff, Stride: 1}, // Katakana
+}}
+
+// langScripts maps languages written in a ttsScript to that script, so a default of
+// e.g. "uk" is kept for Cyrillic text instead of being replaced by "ru".
+var langScripts = map[string]string{
+	"ja": "Kana", "zh": "Han", "ko": "Hangul",
+	"ru": "Cyrillic", "uk": "Cyrillic", "be": "Cyrillic", "bg": "Cyrillic", "sr": "Cyrillic", "mk": "Cyrillic", "kk": "Cyrillic",
+	"ar": "Arabic", "fa": "Arabic", "ur": "Arabic",
+	"he": "Hebrew", "yi": "Hebrew",
+	"el": "Greek",
+	"hi": "Devanagari", "mr": "Devanagari", "ne": "Devanagari",
+	"th": "Thai",
+}
+
+// detectTTSScript returns the non-Latin script making up at least half of the letters
+// in text. Latin text, or Latin text with a few foreign words, is not confident.
+func detectTTSScript(text string) (ttsScript, bool) {
+	counts := make([]int, len(ttsScripts))
+	letters := 0
+	hasKana := false
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for i, s := range ttsScripts {
+			if unicode.Is(s.table, r) {
+				counts[i]++
+				if s.name == "Kana" {
+					hasKana = true
+				}
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ttsScript{}, false
+	}
+	best := -1
+	for i, n := range counts {
+		if n > 0 && (best < 0 || n > counts[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ttsScript{}, false
+	}
+	n := counts[best]
+	s := ttsScripts[best]
+	if hasKana && (s.name == "Han" || s.name == "Kana") {
+		// Japanese mixes kanji and kana; count both towards Japanese.
+		s = ttsScripts[0]
+		n = counts[0] + counts[1]
+	}
+	if n*2 < letters {
+		return ttsScript{}, false
+	}
+	return s, true
+}
+
+// resolveTTSLang picks the synthesis language: the chat default (or defaultLang) for
+// ambiguous text, overridden by detection only for a clearly different script.
+func resolveTTSLang(text string, chatLang string, defaultLang string) string {
+	fallback := normalizeTTSLang(chatLang)
+	if fallback == "" {
+		fallback = normalizeTTSLang(defaultLang)
+	}
+	if fallback == "" {
+		fallback = defaultVoiceLang
+	}
+	s, ok := detectTTSScript(text)
+	if !ok {
+		return fallback
+	}
+	if script := langScripts[ttsLangBase(fallback)]; script == s.name || (script == "Kana" && s.name == "Han") {
+		return fallback
+	}
+	return s.lang
+}
+
+func normalizeTTSLang(lang string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(lang)), "_", "-")
+}
+
+func ttsLangBase(lang string) string {
+	if i := strings.IndexByte(lang, '-'); i >= 0 {
+		return lang[:i]
+	}
+	return lang
+}
+
+// voiceChatLangsFromViper reads tools.voice.chat_langs (chat id -> language).
+func voiceChatLangsFromViper() map[int64]string {
+	out := make(map[int64]string)
+	for k, v := range viper.GetStringMapString("tools.voice.chat_langs") {
+		id, err := strconv.ParseInt(strings.TrimSpace(k), 10, 64)
+		if err != nil || id == 0 {
+			continue
+		}
+		if lang := normalizeTTSLang(v); lang != "" {
+			out[id] = lang
+		}
+	}
+	return out
+}
+
+// picoLangs are the voices shipped with pico2wave.
+var picoLangs = map[string]string{
+	"en": "en-US", "en-us": "en-US", "en-gb": "en-GB",
+	"de": "de-DE", "es": "es-ES", "fr": "fr-FR", "it": "it-IT",
+}
+
+// ttsSynthCommand builds the command writing text as WAV to wavPath in lang.
+// pico2wave is preferred when it has a voice for lang, then espeak-ng/espeak (which
+// cover most languages); pico2wave and flite remain English-only fallbacks.
+func ttsSynthCommand(ctx context.Context, lang string, wavPath string, text string) (*exec.Cmd, bool) {
+	lang = normalizeTTSLang(lang)
+	if lang == "" {
+		lang = defaultVoiceLang
+	}
+	pico, picoOK := picoLangs[lang]
+	if !picoOK {
+		pico, picoOK = picoLangs[ttsLangBase(lang)]
+	}
+	switch {
+	case picoOK && commandExists("pico2wave"):
+		// pico2wave writes the WAV file directly.
+		return exec.CommandContext(ctx, "pico2wave", "-l", pico, "-w", wavPath, text), true
+	case commandExists("espeak-ng"):
+		return exec.CommandContext(ctx, "espeak-ng", "-v", lang, "-w", wavPath, text), true
+	case commandExists("espeak"):
+		return exec.CommandContext(ctx, "espeak", "-v", lang, "-w", wavPath, text), true
+	case commandExists("pico2wave"):
+		return exec.CommandContext(ctx, "pico2wave", "-l", "en-US", "-w", wavPath, text), true
+	case commandExists("flite"):
+		return exec.CommandContext(ctx, "flite", "-t", text, "-o", wavPath), true
+	}
+	return nil, false
+}
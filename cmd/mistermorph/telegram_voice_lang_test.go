@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestResolveTTSLang(t *testing.T) {
+	cases := []struct {
+		name        string
+		text        string
+		chatLang    string
+		defaultLang string
+		want        string
+	}{
+		{name: "no_config_latin", text: "Good morning, here is the report.", want: "en"},
+		{name: "chat_default_for_latin", text: "Guten Morgen, hier ist der Bericht.", chatLang: "de", want: "de"},
+		{name: "chat_default_beats_global", text: "Bonjour à tous", chatLang: "fr", defaultLang: "de", want: "fr"},
+		{name: "global_default", text: "Buongiorno", defaultLang: "it", want: "it"},
+		{name: "foreign_words_keep_default", text: "We had 寿司 and борщ for lunch with the whole team today.", chatLang: "en", want: "en"},
+		{name: "cyrillic_overrides", text: "Доброе утро, вот отчёт.", chatLang: "en", want: "ru"},
+		{name: "cyrillic_keeps_matching_default", text: "Доброго ранку, ось звіт.", chatLang: "uk", want: "uk"},
+		{name: "han_overrides", text: "今天的天气很好", chatLang: "de", want: "zh"},
+		{name: "kana_is_japanese", text: "今日はいい天気ですね", chatLang: "en", want: "ja"},
+		{name: "han_keeps_japanese_default", text: "東京都庁", chatLang: "ja", want: "ja"},
+		{name: "hangul", text: "안녕하세요", want: "ko"},
+		{name: "normalized_default", text: "hello", chatLang: " EN_gb ", want: "en-gb"},
+		{name: "no_letters", text: "12:30 !!", chatLang: "es", want: "es"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := resolveTTSLang(tc.text, tc.chatLang, tc.defaultLang); got != tc.want {
+				t.Fatalf("resolveTTSLang(%q, %q, %q) = %q, want %q", tc.text, tc.chatLang, tc.defaultLang, got, tc.want)
+			}
+		})
+	}
+}
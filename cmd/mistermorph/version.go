@@ -2,16 +2,29 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+// version is the build version. Override at build time with:
+//
+//	go build -ldflags "-X main.version=v1.2.3" ./cmd/mistermorph
+var version = "dev"
+
+func buildVersion() string {
+	if v := strings.TrimSpace(version); v != "" {
+		return v
+	}
+	return "dev"
+}
+
 func newVersionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
 		Short: "Print version",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("mistermorph dev")
+			fmt.Println("mistermorph " + buildVersion())
 			return nil
 		},
 	}
@@ -11,10 +11,24 @@ func AutoMigrate(gdb *gorm.DB) error {
 	if gdb == nil {
 		return fmt.Errorf("nil gorm db")
 	}
-	return gdb.AutoMigrate(
+	// cron_runs.notified_at is nullable; rows that finished before it existed were
+	// already notified (or never will be) and must not look undelivered.
+	m := gdb.Migrator()
+	backfillNotified := m.HasTable(&models.CronRun{}) && !m.HasColumn(&models.CronRun{}, "NotifiedAt")
+	if err := gdb.AutoMigrate(
 		&models.MemoryItem{},
 		&models.IdentityLink{},
 		&models.CronJob{},
 		&models.CronRun{},
-	)
+	); err != nil {
+		return err
+	}
+	if backfillNotified {
+		if err := gdb.Model(&models.CronRun{}).
+			Where("notified_at IS NULL AND finished_at IS NOT NULL").
+			Update("notified_at", gorm.Expr("finished_at")).Error; err != nil {
+			return fmt.Errorf("backfill cron_runs.notified_at: %w", err)
+		}
+	}
+	return nil
 }
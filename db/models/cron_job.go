@@ -18,6 +18,9 @@ type CronJob struct {
 	// Agent input
 	Task string `gorm:"type:text;not null"`
 
+	// Optional structured parameters (JSON object), passed to each run as meta["params"].
+	Params *string `gorm:"type:text"`
+
 	// If true, disable the job after its next scheduled enqueue (one-shot execution).
 	RunOnce bool `gorm:"not null;default:0"`
 
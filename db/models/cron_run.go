@@ -27,6 +27,9 @@ type CronRun struct {
 	Error         *string `gorm:"type:text"`
 	ResultSummary *string `gorm:"type:text"`
 
+	// UTC unix seconds when Config.OnRunFinished last succeeded for this run; nil = not yet delivered.
+	NotifiedAt *int64 `gorm:""`
+
 	CreatedAt int64 `gorm:"autoCreateTime"`
 	UpdatedAt int64 `gorm:"autoUpdateTime"`
 }
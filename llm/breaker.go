@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a CircuitBreaker while it is rejecting requests.
+var ErrCircuitOpen = errors.New("llm: circuit open after repeated provider failures")
+
+const defaultBreakerCooldown = 30 * time.Second
+
+// BreakerConfig configures a CircuitBreaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed calls that opens the
+	// circuit. 0 disables the breaker.
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a single probe call is
+	// let through (default 30s).
+	Cooldown time.Duration
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker wraps a Client and, after FailureThreshold consecutive failures,
+// fails calls fast with ErrCircuitOpen for Cooldown. After the cooldown one probe
+// call is allowed (half-open): success closes the circuit, failure reopens it.
+// Calls that fail because the caller's context ended are not counted.
+type CircuitBreaker struct {
+	inner Client
+	cfg   BreakerConfig
+	now   func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker wraps inner. With FailureThreshold <= 0 it returns inner unchanged.
+func NewCircuitBreaker(inner Client, cfg BreakerConfig) Client {
+	if inner == nil || cfg.FailureThreshold <= 0 {
+		return inner
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaultBreakerCooldown
+	}
+	return &CircuitBreaker{inner: inner, cfg: cfg, now: time.Now}
+}
+
+func (b *CircuitBreaker) Chat(ctx context.Context, req Request) (Result, error) {
+	if err := b.allow(); err != nil {
+		return Result{}, err
+	}
+	res, err := b.inner.Chat(ctx, req)
+	b.record(ctx, err)
+	return res, err
+}
+
+// Embed forwards to the wrapped client's Embedder through the same breaker.
+func (b *CircuitBreaker) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	e, ok := b.inner.(Embedder)
+	if !ok {
+		return nil, ErrEmbeddingsUnsupported
+	}
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	out, err := e.Embed(ctx, model, inputs)
+	b.record(ctx, err)
+	return out, err
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		remaining := b.cfg.Cooldown - b.now().Sub(b.openedAt)
+		if remaining > 0 {
+			return fmt.Errorf("%w (retry in %s)", ErrCircuitOpen, remaining.Round(time.Second))
+		}
+		b.state = breakerHalfOpen
+		return nil
+	case breakerHalfOpen:
+		// A probe is already in flight.
+		return fmt.Errorf("%w (probing recovery)", ErrCircuitOpen)
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil && ctx != nil && ctx.Err() != nil {
+		// The caller gave up; this says nothing about provider health.
+		if b.state == breakerHalfOpen {
+			b.state = breakerOpen
+		}
+		return
+	}
+	if err == nil {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
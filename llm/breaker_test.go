@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type scriptedClient struct {
+	errs  []error // returned in order; nil entries succeed
+	calls int
+}
+
+func (c *scriptedClient) Chat(ctx context.Context, req Request) (Result, error) {
+	i := c.calls
+	c.calls++
+	if i < len(c.errs) && c.errs[i] != nil {
+		return Result{}, c.errs[i]
+	}
+	return Result{Text: "ok"}, nil
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	outage := errors.New("openai http 503: unavailable")
+	inner := &scriptedClient{errs: []error{outage, outage, outage, outage, nil}}
+	now := time.Unix(1_700_000_000, 0)
+	c := NewCircuitBreaker(inner, BreakerConfig{FailureThreshold: 3, Cooldown: time.Minute})
+	b := c.(*CircuitBreaker)
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Failures below the threshold reach the provider.
+	for i := 0; i < 3; i++ {
+		if _, err := c.Chat(ctx, Request{}); !errors.Is(err, outage) {
+			t.Fatalf("call %d: err = %v, want provider error", i, err)
+		}
+	}
+	if inner.calls != 3 {
+		t.Fatalf("provider calls = %d, want 3", inner.calls)
+	}
+
+	// Open: fail fast without calling the provider.
+	for i := 0; i < 5; i++ {
+		if _, err := c.Chat(ctx, Request{}); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("open call %d: err = %v, want ErrCircuitOpen", i, err)
+		}
+	}
+	if inner.calls != 3 {
+		t.Fatalf("provider called while open: %d calls", inner.calls)
+	}
+
+	// After the cooldown a failing probe reopens the circuit immediately.
+	now = now.Add(time.Minute)
+	if _, err := c.Chat(ctx, Request{}); !errors.Is(err, outage) {
+		t.Fatalf("probe err = %v, want provider error", err)
+	}
+	if _, err := c.Chat(ctx, Request{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("after failed probe err = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes it again.
+	now = now.Add(time.Minute)
+	if res, err := c.Chat(ctx, Request{}); err != nil || res.Text != "ok" {
+		t.Fatalf("recovery probe = %+v, %v", res, err)
+	}
+	if _, err := c.Chat(ctx, Request{}); err != nil {
+		t.Fatalf("after recovery err = %v", err)
+	}
+	if inner.calls != 6 {
+		t.Fatalf("provider calls = %d, want 6", inner.calls)
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsAndCancelIgnored(t *testing.T) {
+	outage := errors.New("boom")
+	inner := &scriptedClient{errs: []error{outage, nil, outage, context.Canceled, outage}}
+	c := NewCircuitBreaker(inner, BreakerConfig{FailureThreshold: 2})
+
+	ctx := context.Background()
+	c.Chat(ctx, Request{}) // failure 1
+	c.Chat(ctx, Request{}) // success resets
+	c.Chat(ctx, Request{}) // failure 1
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	c.Chat(canceled, Request{}) // caller cancellation is not counted
+
+	if _, err := c.Chat(ctx, Request{}); !errors.Is(err, outage) {
+		t.Fatalf("err = %v, want provider error (circuit should still be closed)", err)
+	}
+	if _, err := c.Chat(ctx, Request{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen after 2 consecutive failures", err)
+	}
+}
+
+func TestNewCircuitBreaker_Disabled(t *testing.T) {
+	inner := &scriptedClient{}
+	if c := NewCircuitBreaker(inner, BreakerConfig{}); c != Client(inner) {
+		t.Fatalf("disabled breaker should return the inner client, got %T", c)
+	}
+	if _, err := Embed(context.Background(), NewCircuitBreaker(inner, BreakerConfig{FailureThreshold: 1}), "m", []string{"x"}); !errors.Is(err, ErrEmbeddingsUnsupported) {
+		t.Fatalf("Embed err = %v, want ErrEmbeddingsUnsupported", err)
+	}
+}
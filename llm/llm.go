@@ -2,12 +2,60 @@ package llm
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
 	"time"
 )
 
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Images are optional image inputs sent alongside Content to vision-capable models.
+	// Providers encode them into their multimodal format; they are not part of the JSON form.
+	Images []ImagePart `json:"-"`
+}
+
+// ImagePart is an image input given either by URL or as raw bytes (sent base64-encoded).
+type ImagePart struct {
+	URL      string
+	MIMEType string // required with Data, e.g. "image/jpeg"
+	Data     []byte
+}
+
+// DataURL returns the URL to send for the image, encoding Data as a data: URL when URL is empty.
+func (p ImagePart) DataURL() string {
+	if strings.TrimSpace(p.URL) != "" {
+		return strings.TrimSpace(p.URL)
+	}
+	if len(p.Data) == 0 {
+		return ""
+	}
+	mime := strings.TrimSpace(p.MIMEType)
+	if mime == "" {
+		mime = "image/jpeg"
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(p.Data)
+}
+
+// visionModelPrefixes lists model families known to accept image input.
+var visionModelPrefixes = []string{
+	"gpt-4o", "gpt-4.1", "gpt-4-turbo", "gpt-4-vision", "gpt-5", "o1", "o3", "o4",
+	"claude-3", "claude-sonnet-4", "claude-opus-4", "gemini",
+}
+
+// SupportsVision reports whether model is known to accept image inputs.
+func SupportsVision(model string) bool {
+	m := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(m, "/"); i >= 0 {
+		m = m[i+1:] // e.g. "openai/gpt-4o" via a router
+	}
+	for _, p := range visionModelPrefixes {
+		if strings.HasPrefix(m, p) {
+			return true
+		}
+	}
+	return false
 }
 
 type Usage struct {
@@ -31,6 +79,57 @@ type Request struct {
 	Parameters map[string]any
 }
 
+// ParamReasoningTokenBudget is the Request.Parameters key (int) capping the tokens a
+// model may spend on reasoning/thinking, separate from its output budget. The openai
+// provider maps it onto reasoning_effort for reasoning models; others ignore it.
+const ParamReasoningTokenBudget = "reasoning_token_budget"
+
+// ParamReasoningEffort is the Request.Parameters key (string: "low", "medium" or "high")
+// setting the openai reasoning_effort directly. It overrides the value derived from
+// ParamReasoningTokenBudget; other providers ignore it.
+const ParamReasoningEffort = "reasoning_effort"
+
 type Client interface {
 	Chat(ctx context.Context, req Request) (Result, error)
 }
+
+// ErrEmbeddingsUnsupported is returned by Embed when the provider has no embeddings API.
+var ErrEmbeddingsUnsupported = errors.New("llm: embeddings not supported by this provider")
+
+// Embedder is implemented by clients that can compute embeddings directly.
+// Vectors are returned in the same order as inputs.
+type Embedder interface {
+	Embed(ctx context.Context, model string, inputs []string) ([][]float32, error)
+}
+
+// Embed computes embeddings via client if it implements Embedder,
+// and returns ErrEmbeddingsUnsupported otherwise.
+func Embed(ctx context.Context, client Client, model string, inputs []string) ([][]float32, error) {
+	e, ok := client.(Embedder)
+	if !ok {
+		return nil, ErrEmbeddingsUnsupported
+	}
+	return e.Embed(ctx, model, inputs)
+}
+
+type retryObserverKey struct{}
+
+// WithRetryObserver returns a context whose provider-level retries are reported
+// to fn, e.g. so a task runner can record how many attempts a run needed.
+func WithRetryObserver(ctx context.Context, fn func(err error)) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, retryObserverKey{}, fn)
+}
+
+// ReportRetry tells the observer attached to ctx, if any, that a call failed
+// with err and is about to be retried.
+func ReportRetry(ctx context.Context, err error) {
+	if ctx == nil || err == nil {
+		return
+	}
+	if fn, ok := ctx.Value(retryObserverKey{}).(func(error)); ok {
+		fn(err)
+	}
+}
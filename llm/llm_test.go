@@ -1,6 +1,10 @@
 package llm
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"testing"
+)
 
 func TestUsageCostField(t *testing.T) {
 	u := Usage{
@@ -20,3 +24,44 @@ func TestUsageCostDefaultsToZero(t *testing.T) {
 		t.Errorf("expected Cost to default to 0, got %f", u.Cost)
 	}
 }
+
+type chatOnlyClient struct{}
+
+func (chatOnlyClient) Chat(ctx context.Context, req Request) (Result, error) { return Result{}, nil }
+
+type embeddingClient struct{ chatOnlyClient }
+
+func (embeddingClient) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	out := make([][]float32, len(inputs))
+	for i := range inputs {
+		out[i] = []float32{float32(i)}
+	}
+	return out, nil
+}
+
+func TestEmbed_UnsupportedProvider(t *testing.T) {
+	_, err := Embed(context.Background(), chatOnlyClient{}, "m", []string{"a"})
+	if !errors.Is(err, ErrEmbeddingsUnsupported) {
+		t.Fatalf("expected ErrEmbeddingsUnsupported, got %v", err)
+	}
+	vecs, err := Embed(context.Background(), embeddingClient{}, "m", []string{"a", "b"})
+	if err != nil || len(vecs) != 2 || vecs[1][0] != 1 {
+		t.Fatalf("Embed = %v, %v", vecs, err)
+	}
+}
+
+func TestReportRetry(t *testing.T) {
+	// No observer attached: must be a no-op.
+	ReportRetry(context.Background(), errors.New("ignored"))
+
+	var got []string
+	ctx := WithRetryObserver(context.Background(), func(err error) {
+		got = append(got, err.Error())
+	})
+	ReportRetry(ctx, errors.New("first"))
+	ReportRetry(ctx, nil)
+	ReportRetry(ctx, errors.New("second"))
+	if len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Fatalf("observed = %v", got)
+	}
+}
@@ -1,7 +1,6 @@
 package openai
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -20,6 +19,12 @@ type Client struct {
 	APIKey           string
 	HTTP             *http.Client
 	MaxResponseBytes int64
+	// MaxRetries bounds how many times a rate-limited (429) request is retried.
+	MaxRetries int
+	// MaxRetryWait caps the wait between retries, including waits requested via Retry-After.
+	MaxRetryWait time.Duration
+
+	sleepFn func(ctx context.Context, d time.Duration) error // overridden in tests
 }
 
 func New(baseURL, apiKey string) *Client {
@@ -31,14 +36,121 @@ func New(baseURL, apiKey string) *Client {
 		APIKey:           apiKey,
 		HTTP:             &http.Client{Timeout: 90 * time.Second},
 		MaxResponseBytes: defaultMaxResponseBytes,
+		MaxRetries:       defaultMaxRetries,
+		MaxRetryWait:     defaultMaxRetryWait,
 	}
 }
 
 type chatCompletionRequest struct {
-	Model          string        `json:"model"`
-	Messages       []llm.Message `json:"messages"`
-	Temperature    float64       `json:"temperature,omitempty"`
-	ResponseFormat any           `json:"response_format,omitempty"`
+	Model           string        `json:"model"`
+	Messages        []chatMessage `json:"messages"`
+	Temperature     float64       `json:"temperature,omitempty"`
+	ResponseFormat  any           `json:"response_format,omitempty"`
+	ReasoningEffort string        `json:"reasoning_effort,omitempty"`
+}
+
+// reasoningModelPrefixes lists the model families OpenAI documents as reasoning models
+// (the o-series and gpt-5); they accept reasoning_effort, other chat models reject it.
+var reasoningModelPrefixes = []string{"o1", "o3", "o4", "gpt-5"}
+
+// reasoningEffort picks the reasoning_effort to send. An explicit llm.ParamReasoningEffort
+// wins and is sent as given. Otherwise llm.ParamReasoningTokenBudget is mapped onto an
+// effort, since the Chat Completions API has no token budget for reasoning. OpenAI does
+// not publish token counts per effort level, so the cutoffs are this package's own
+// heuristic: up to 2048 tokens is low, up to 8192 medium, anything larger high. It
+// returns "" when neither is set or the model is not a reasoning model.
+func reasoningEffort(model string, params map[string]any) string {
+	if v, ok := params[llm.ParamReasoningEffort].(string); ok && strings.TrimSpace(v) != "" {
+		return strings.ToLower(strings.TrimSpace(v))
+	}
+	var budget int
+	switch v := params[llm.ParamReasoningTokenBudget].(type) {
+	case int:
+		budget = v
+	case int64:
+		budget = int(v)
+	case float64:
+		budget = int(v)
+	}
+	if budget <= 0 {
+		return ""
+	}
+	m := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(m, "/"); i >= 0 {
+		m = m[i+1:]
+	}
+	supported := false
+	for _, p := range reasoningModelPrefixes {
+		if strings.HasPrefix(m, p) {
+			supported = true
+			break
+		}
+	}
+	switch {
+	case !supported:
+		return ""
+	case budget <= 2048:
+		return "low"
+	case budget <= 8192:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// chatMessage is the wire form of llm.Message. Content is a plain string for
+// text-only messages and a list of content parts when images are attached.
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+type chatContentPart struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *chatImageURL `json:"image_url,omitempty"`
+}
+
+type chatImageURL struct {
+	URL string `json:"url"`
+}
+
+// buildChatMessages translates messages to the wire format. Images are only
+// sent to models that accept them; for other models they are dropped and the
+// text notes the omission so the model doesn't assume it saw them.
+func buildChatMessages(model string, msgs []llm.Message) []chatMessage {
+	vision := llm.SupportsVision(model)
+	out := make([]chatMessage, 0, len(msgs))
+	for _, m := range msgs {
+		var urls []string
+		for _, img := range m.Images {
+			if u := img.DataURL(); u != "" {
+				urls = append(urls, u)
+			}
+		}
+		if len(urls) == 0 {
+			out = append(out, chatMessage{Role: m.Role, Content: m.Content})
+			continue
+		}
+		if !vision {
+			text := m.Content
+			note := fmt.Sprintf("[%d image(s) omitted: model does not accept image input]", len(urls))
+			if strings.TrimSpace(text) != "" {
+				text += "\n\n"
+			}
+			out = append(out, chatMessage{Role: m.Role, Content: text + note})
+			continue
+		}
+		parts := make([]chatContentPart, 0, len(urls)+1)
+		if strings.TrimSpace(m.Content) != "" {
+			parts = append(parts, chatContentPart{Type: "text", Text: m.Content})
+		}
+		for _, u := range urls {
+			parts = append(parts, chatContentPart{Type: "image_url", ImageURL: &chatImageURL{URL: u}})
+		}
+		out = append(out, chatMessage{Role: m.Role, Content: parts})
+	}
+	return out
 }
 
 type chatCompletionResponse struct {
@@ -63,9 +175,10 @@ func (c *Client) Chat(ctx context.Context, req llm.Request) (llm.Result, error)
 
 	do := func(forceJSON bool) (llm.Result, *chatCompletionResponse, int, []byte, error) {
 		body := chatCompletionRequest{
-			Model:       req.Model,
-			Messages:    req.Messages,
-			Temperature: 0,
+			Model:           req.Model,
+			Messages:        buildChatMessages(req.Model, req.Messages),
+			Temperature:     0,
+			ReasoningEffort: reasoningEffort(req.Model, req.Parameters),
 		}
 		if forceJSON {
 			body.ResponseFormat = map[string]string{"type": "json_object"}
@@ -76,16 +189,7 @@ func (c *Client) Chat(ctx context.Context, req llm.Request) (llm.Result, error)
 			return llm.Result{}, nil, 0, nil, err
 		}
 
-		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/v1/chat/completions", bytes.NewReader(b))
-		if err != nil {
-			return llm.Result{}, nil, 0, nil, err
-		}
-		httpReq.Header.Set("Content-Type", "application/json")
-		if c.APIKey != "" {
-			httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
-		}
-
-		resp, err := c.HTTP.Do(httpReq)
+		resp, err := c.post(ctx, "/v1/chat/completions", b)
 		if err != nil {
 			return llm.Result{}, nil, 0, nil, err
 		}
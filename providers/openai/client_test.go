@@ -2,10 +2,12 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/quailyquaily/mistermorph/llm"
 )
@@ -75,3 +77,283 @@ func TestClient_NormalResponseParsed(t *testing.T) {
 		t.Fatalf("expected text %q, got %q", "hello", res.Text)
 	}
 }
+
+func TestClient_EmbedOrderAndDimensions(t *testing.T) {
+	// Out-of-order data must be returned in input order.
+	body := `{"data":[{"index":1,"embedding":[0.4,0.5,0.6]},{"index":0,"embedding":[0.1,0.2,0.3]}]}`
+	var gotPath, gotBody string
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		gotPath = r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})
+	c := New("http://fake.test", "key")
+	c.HTTP = &http.Client{Transport: rt}
+
+	vecs, err := llm.Embed(context.Background(), c, "text-embedding-3-small", []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if gotPath != "/v1/embeddings" || !strings.Contains(gotBody, `"input":["first","second"]`) {
+		t.Fatalf("request path=%q body=%q", gotPath, gotBody)
+	}
+	if len(vecs) != 2 || len(vecs[0]) != 3 || len(vecs[1]) != 3 {
+		t.Fatalf("unexpected dimensions: %v", vecs)
+	}
+	if vecs[0][0] != 0.1 || vecs[1][0] != 0.4 {
+		t.Fatalf("unexpected order: %v", vecs)
+	}
+}
+
+func TestClient_EmbedErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{name: "api_error", status: 400, body: `{"error":{"message":"bad model"}}`, want: "openai http 400: bad model"},
+		{name: "count_mismatch", status: 200, body: `{"data":[{"index":0,"embedding":[1]}]}`, want: "expected 2 embeddings"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: tc.status, Body: io.NopCloser(strings.NewReader(tc.body)), Request: r}, nil
+			})
+			c := New("http://fake.test", "key")
+			c.HTTP = &http.Client{Transport: rt}
+			_, err := c.Embed(context.Background(), "m", []string{"a", "b"})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestClient_ImagePartsEncoded(t *testing.T) {
+	var sent string
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		sent = string(b)
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"choices":[{"message":{"content":"a cat"}}]}`)),
+			Request:    r,
+		}, nil
+	})
+	c := New("http://fake.test", "key")
+	c.HTTP = &http.Client{Transport: rt}
+
+	_, err := c.Chat(context.Background(), llm.Request{
+		Model: "gpt-4o-mini",
+		Messages: []llm.Message{{
+			Role:    "user",
+			Content: "what is this?",
+			Images: []llm.ImagePart{
+				{URL: "https://example.com/cat.png"},
+				{MIMEType: "image/png", Data: []byte("png")},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	want := `"messages":[{"role":"user","content":[{"type":"text","text":"what is this?"},` +
+		`{"type":"image_url","image_url":{"url":"https://example.com/cat.png"}},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}}]}]`
+	if !strings.Contains(sent, want) {
+		t.Fatalf("request body = %s\nwant messages %s", sent, want)
+	}
+}
+
+func TestClient_ReasoningBudgetSentAsEffort(t *testing.T) {
+	cases := []struct {
+		name   string
+		model  string
+		budget any
+		effort string
+		want   string
+	}{
+		{name: "unset", model: "o3-mini", want: ""},
+		{name: "low", model: "o3-mini", budget: 1024, want: "low"},
+		{name: "low_upper_bound", model: "o3-mini", budget: 2048, want: "low"},
+		{name: "medium_lower_bound", model: "o3-mini", budget: 2049, want: "medium"},
+		{name: "medium", model: "openai/o4-mini", budget: 4096, want: "medium"},
+		{name: "medium_upper_bound", model: "o1", budget: int64(8192), want: "medium"},
+		{name: "high_lower_bound", model: "o1", budget: 8193, want: "high"},
+		{name: "high", model: "gpt-5", budget: float64(32000), want: "high"},
+		{name: "non_reasoning_model", model: "gpt-4o-mini", budget: 4096, want: ""},
+		{name: "explicit_overrides_budget", model: "o3-mini", budget: 1024, effort: "High", want: "high"},
+		{name: "explicit_without_budget", model: "o3-mini", effort: "medium", want: "medium"},
+		{name: "blank_explicit_ignored", model: "o3-mini", budget: 1024, effort: "  ", want: "low"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var sent map[string]any
+			rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(b, &sent)
+				return &http.Response{
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"choices":[{"message":{"content":"ok"}}]}`)),
+					Request:    r,
+				}, nil
+			})
+			c := New("http://fake.test", "key")
+			c.HTTP = &http.Client{Transport: rt}
+
+			req := llm.Request{Model: tc.model, Messages: []llm.Message{{Role: "user", Content: "hi"}}}
+			req.Parameters = map[string]any{}
+			if tc.budget != nil {
+				req.Parameters[llm.ParamReasoningTokenBudget] = tc.budget
+			}
+			if tc.effort != "" {
+				req.Parameters[llm.ParamReasoningEffort] = tc.effort
+			}
+			if _, err := c.Chat(context.Background(), req); err != nil {
+				t.Fatalf("Chat: %v", err)
+			}
+			got, _ := sent["reasoning_effort"].(string)
+			if got != tc.want {
+				t.Fatalf("reasoning_effort = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildChatMessages(t *testing.T) {
+	img := []llm.ImagePart{{URL: "https://example.com/a.jpg"}}
+	cases := []struct {
+		name  string
+		model string
+		msg   llm.Message
+		want  string
+	}{
+		{"text only", "gpt-4o", llm.Message{Role: "user", Content: "hi"}, `{"role":"user","content":"hi"}`},
+		{"text only non-vision", "gpt-3.5-turbo", llm.Message{Role: "user", Content: "hi"}, `{"role":"user","content":"hi"}`},
+		{"images dropped for non-vision model", "gpt-3.5-turbo", llm.Message{Role: "user", Content: "hi", Images: img},
+			`{"role":"user","content":"hi\n\n[1 image(s) omitted: model does not accept image input]"}`},
+		{"routed vision model", "openai/gpt-4o", llm.Message{Role: "user", Images: img},
+			`{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.jpg"}}]}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(buildChatMessages(tc.model, []llm.Message{tc.msg})[0])
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if string(b) != tc.want {
+				t.Fatalf("got %s\nwant %s", b, tc.want)
+			}
+		})
+	}
+}
+
+func TestClient_RetryAfterOn429(t *testing.T) {
+	cases := []struct {
+		name       string
+		retryAfter string
+		maxWait    time.Duration
+		want       time.Duration
+	}{
+		{name: "seconds", retryAfter: "2", want: 2 * time.Second},
+		{name: "capped", retryAfter: "120", maxWait: 5 * time.Second, want: 5 * time.Second},
+		{name: "missing_header_uses_backoff", retryAfter: "", want: defaultRetryBackoff},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				calls++
+				b, _ := io.ReadAll(r.Body)
+				if !strings.Contains(string(b), `"model":"m"`) {
+					t.Fatalf("attempt %d sent body %q", calls, b)
+				}
+				if calls == 1 {
+					h := http.Header{}
+					if tc.retryAfter != "" {
+						h.Set("Retry-After", tc.retryAfter)
+					}
+					return &http.Response{StatusCode: 429, Header: h, Body: io.NopCloser(strings.NewReader(`{"error":{"message":"rate limited"}}`)), Request: r}, nil
+				}
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"choices":[{"message":{"content":"ok"}}]}`)), Request: r}, nil
+			})
+			c := New("http://fake.test", "key")
+			c.HTTP = &http.Client{Transport: rt}
+			if tc.maxWait > 0 {
+				c.MaxRetryWait = tc.maxWait
+			}
+			var waits []time.Duration
+			c.sleepFn = func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+
+			var retried []error
+			ctx := llm.WithRetryObserver(context.Background(), func(err error) { retried = append(retried, err) })
+			res, err := c.Chat(ctx, llm.Request{Model: "m", Messages: []llm.Message{{Role: "user", Content: "hi"}}})
+			if err != nil {
+				t.Fatalf("Chat: %v", err)
+			}
+			if res.Text != "ok" || calls != 2 {
+				t.Fatalf("text=%q calls=%d", res.Text, calls)
+			}
+			if len(retried) != 1 || !strings.Contains(retried[0].Error(), "429") {
+				t.Fatalf("retries reported = %v", retried)
+			}
+			if len(waits) != 1 || waits[0] != tc.want {
+				t.Fatalf("waits = %v, want [%s]", waits, tc.want)
+			}
+		})
+	}
+}
+
+func TestClient_429GivesUpAfterMaxRetries(t *testing.T) {
+	calls := 0
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": []string{"1"}}, Body: io.NopCloser(strings.NewReader(`{"error":{"message":"rate limited"}}`)), Request: r}, nil
+	})
+	c := New("http://fake.test", "key")
+	c.HTTP = &http.Client{Transport: rt}
+	c.sleepFn = func(ctx context.Context, d time.Duration) error { return nil }
+
+	_, err := c.Chat(context.Background(), llm.Request{Model: "m", Messages: []llm.Message{{Role: "user", Content: "hi"}}})
+	if err == nil || !strings.Contains(err.Error(), "openai http 429: rate limited") {
+		t.Fatalf("err = %v", err)
+	}
+	if calls != defaultMaxRetries+1 {
+		t.Fatalf("calls = %d, want %d", calls, defaultMaxRetries+1)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"2", 2 * time.Second, true},
+		{" 0 ", 0, true},
+		{"-1", 0, false},
+		{"", 0, false},
+		{"soon", 0, false},
+		{now.Add(7 * time.Second).Format(http.TimeFormat), 7 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+	}
+	for _, tc := range cases {
+		got, ok := parseRetryAfter(tc.in, now)
+		if got != tc.want || ok != tc.wantOK {
+			t.Fatalf("parseRetryAfter(%q) = %s, %v; want %s, %v", tc.in, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+type embeddingsRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Embed calls /v1/embeddings and returns one vector per input, in input order.
+func (c *Client) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(embeddingsRequest{Model: model, Input: inputs})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.post(ctx, "/v1/embeddings", b)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	maxResp := c.MaxResponseBytes
+	if maxResp <= 0 {
+		maxResp = defaultMaxResponseBytes
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResp))
+	if err != nil {
+		return nil, err
+	}
+
+	var out embeddingsResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("openai http %d: %s", resp.StatusCode, string(raw))
+		}
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if out.Error != nil && out.Error.Message != "" {
+			return nil, fmt.Errorf("openai http %d: %s", resp.StatusCode, out.Error.Message)
+		}
+		return nil, fmt.Errorf("openai http %d: %s", resp.StatusCode, string(raw))
+	}
+	if len(out.Data) != len(inputs) {
+		return nil, fmt.Errorf("openai: expected %d embeddings, got %d", len(inputs), len(out.Data))
+	}
+
+	// The API reports an index per item; don't rely on response order.
+	sort.Slice(out.Data, func(i, j int) bool { return out.Data[i].Index < out.Data[j].Index })
+	vecs := make([][]float32, len(out.Data))
+	for i, d := range out.Data {
+		if d.Index != i {
+			return nil, fmt.Errorf("openai: unexpected embedding index %d", d.Index)
+		}
+		vecs[i] = d.Embedding
+	}
+	return vecs, nil
+}
@@ -0,0 +1,104 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/llm"
+)
+
+const (
+	defaultMaxRetries   = 2
+	defaultMaxRetryWait = 30 * time.Second
+	defaultRetryBackoff = 1 * time.Second
+)
+
+// post sends a JSON body to path. A 429 response is retried up to MaxRetries
+// times, waiting for the server's Retry-After (capped at MaxRetryWait) or a
+// doubling backoff when the header is absent. Each retry is reported via llm.ReportRetry.
+func (c *Client) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	maxRetries := c.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	maxWait := c.MaxRetryWait
+	if maxWait <= 0 {
+		maxWait = defaultMaxRetryWait
+	}
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if c.APIKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+		}
+
+		resp, err := c.HTTP.Do(httpReq)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= maxRetries {
+			return resp, nil
+		}
+
+		wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			wait = defaultRetryBackoff << attempt
+		}
+		if wait > maxWait {
+			wait = maxWait
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+
+		llm.ReportRetry(ctx, fmt.Errorf("openai http %d: rate limited", resp.StatusCode))
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	if c.sleepFn != nil {
+		return c.sleepFn(ctx, d)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// parseRetryAfter accepts both forms allowed by RFC 9110: delay-seconds and an HTTP-date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		d := at.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
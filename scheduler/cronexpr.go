@@ -16,6 +16,10 @@ type cronExpr struct {
 
 	domAny bool
 	dowAny bool
+
+	// DOW extensions (single token only): "5L" = last Friday, "5#2" = second Friday.
+	dowNth  int
+	dowLast bool
 }
 
 func parseCronExpr(expr string) (*cronExpr, error) {
@@ -40,12 +44,25 @@ func parseCronExpr(expr string) (*cronExpr, error) {
 	if err != nil {
 		return nil, fmt.Errorf("month: %w", err)
 	}
-	dow, dowAny, err := parseFieldWithAny(fields[4], 0, 6)
+	var (
+		dow     *valueSet
+		dowAny  bool
+		dowNth  int
+		dowLast bool
+	)
+	if strings.ContainsAny(fields[4], "L#") {
+		if !domAny {
+			return nil, fmt.Errorf("dow: %q cannot be combined with a day-of-month restriction", fields[4])
+		}
+		dow, dowNth, dowLast, err = parseDOWExtension(fields[4])
+	} else {
+		dow, dowAny, err = parseFieldWithAny(fields[4], 0, 6)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("dow: %w", err)
 	}
 
-	return &cronExpr{
+	e := &cronExpr{
 		minute: min,
 		hour:   hour,
 		dom:    dom,
@@ -53,7 +70,38 @@ func parseCronExpr(expr string) (*cronExpr, error) {
 		dow:    dow,
 		domAny: domAny,
 		dowAny: dowAny,
-	}, nil
+
+		dowNth:  dowNth,
+		dowLast: dowLast,
+	}
+	if !e.dayReachable() {
+		return nil, fmt.Errorf("invalid cron expression: day-of-month %q never occurs in month %q: %q", fields[2], fields[3], expr)
+	}
+	return e, nil
+}
+
+// maxDaysInMonth is the longest each month can be (February counts leap years).
+var maxDaysInMonth = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// dayReachable reports whether some selected month contains a selected day-of-month,
+// so expressions like "0 0 30 2 *" are rejected up front instead of exhausting the
+// search window in next on every reconcile. When both DOM and DOW are restricted
+// they are OR-ed, so any DOW match keeps the expression reachable.
+func (e *cronExpr) dayReachable() bool {
+	if e.domAny || !e.dowAny {
+		return true
+	}
+	for m := 1; m <= 12; m++ {
+		if !e.month.has(m) {
+			continue
+		}
+		for d := 1; d <= maxDaysInMonth[m]; d++ {
+			if e.dom.has(d) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // next returns the next matching time strictly after "after", searching up to 366 days.
@@ -71,7 +119,7 @@ func (e *cronExpr) next(after time.Time) (time.Time, error) {
 			continue
 		}
 		domMatch := e.dom.has(t.Day())
-		dowMatch := e.dow.has(int(t.Weekday()))
+		dowMatch := e.dowMatches(t)
 
 		// Standard cron semantics: if both DOM and DOW are restricted (not "*"),
 		// treat them as OR; otherwise require the non-any field.
@@ -92,6 +140,57 @@ func (e *cronExpr) next(after time.Time) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("no matching time within search window")
 }
 
+func (e *cronExpr) dowMatches(t time.Time) bool {
+	if !e.dow.has(int(t.Weekday())) {
+		return false
+	}
+	switch {
+	case e.dowNth > 0:
+		return (t.Day()-1)/7+1 == e.dowNth
+	case e.dowLast:
+		return t.Day()+7 > daysIn(t.Year(), t.Month())
+	}
+	return true
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// parseDOWExtension parses "<weekday>L" (last such weekday of the month) or
+// "<weekday>#<n>" (n-th such weekday, 1-5). Lists, ranges and steps are rejected.
+func parseDOWExtension(tok string) (*valueSet, int, bool, error) {
+	tok = strings.TrimSpace(tok)
+	if strings.ContainsAny(tok, ",/-*") {
+		return nil, 0, false, fmt.Errorf("%q: L and # cannot be combined with other values", tok)
+	}
+	var (
+		dayStr string
+		nth    int
+		last   bool
+	)
+	switch {
+	case strings.HasSuffix(tok, "L") && strings.Count(tok, "L") == 1 && !strings.Contains(tok, "#"):
+		dayStr = strings.TrimSuffix(tok, "L")
+		last = true
+	case strings.Count(tok, "#") == 1 && !strings.Contains(tok, "L"):
+		var nthStr string
+		dayStr, nthStr, _ = strings.Cut(tok, "#")
+		n, err := strconv.Atoi(nthStr)
+		if err != nil || n < 1 || n > 5 {
+			return nil, 0, false, fmt.Errorf("%q: occurrence after # must be 1-5", tok)
+		}
+		nth = n
+	default:
+		return nil, 0, false, fmt.Errorf("invalid weekday extension %q (use e.g. 5L or 5#2)", tok)
+	}
+	day, err := strconv.Atoi(dayStr)
+	if err != nil || day < 0 || day > 6 {
+		return nil, 0, false, fmt.Errorf("%q: weekday must be 0-6", tok)
+	}
+	return &valueSet{min: 0, max: 6, val: map[int]struct{}{day: {}}}, nth, last, nil
+}
+
 type valueSet struct {
 	min int
 	max int
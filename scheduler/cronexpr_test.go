@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -41,3 +42,79 @@ func TestCronExpr_Invalid(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+func TestParseCronExpr_ImpossibleDays(t *testing.T) {
+	cases := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "0 0 30 2 *", wantErr: true},
+		{expr: "0 0 31 2 *", wantErr: true},
+		{expr: "0 0 31 4,6,9,11 *", wantErr: true},
+		{expr: "0 0 30,31 2 *", wantErr: true},
+		{expr: "0 0 31 4,5 *", wantErr: false},
+		{expr: "0 0 29 2 *", wantErr: false},
+		{expr: "0 0 30 * *", wantErr: false},
+		{expr: "0 0 30 2 1", wantErr: false}, // DOM OR DOW: Mondays in February still match
+		{expr: "0 0 * 2 *", wantErr: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := parseCronExpr(tc.expr)
+			if tc.wantErr && (err == nil || !strings.Contains(err.Error(), "never occurs")) {
+				t.Fatalf("err = %v, want never-occurs error", err)
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCronExpr_Next_DOWExtensions(t *testing.T) {
+	cases := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{name: "last_friday", expr: "0 9 * * 5L", after: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), want: time.Date(2026, 1, 30, 9, 0, 0, 0, time.UTC)},
+		{name: "last_friday_next_month", expr: "0 9 * * 5L", after: time.Date(2026, 1, 30, 9, 0, 0, 0, time.UTC), want: time.Date(2026, 2, 27, 9, 0, 0, 0, time.UTC)},
+		{name: "second_monday", expr: "0 9 * * 1#2", after: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), want: time.Date(2026, 2, 9, 9, 0, 0, 0, time.UTC)},
+		{name: "fifth_sunday_skips_months", expr: "0 0 * * 0#5", after: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), want: time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := parseCronExpr(tc.expr)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			next, err := e.next(tc.after)
+			if err != nil {
+				t.Fatalf("next: %v", err)
+			}
+			if !next.Equal(tc.want) {
+				t.Fatalf("want %s, got %s", tc.want.Format(time.RFC3339), next.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestParseCronExpr_MalformedDOWExtensions(t *testing.T) {
+	for _, expr := range []string{
+		"0 9 * * 5L,3",
+		"0 9 * * 1-5L",
+		"0 9 * * 5#0",
+		"0 9 * * 5#6",
+		"0 9 * * 7L",
+		"0 9 * * L",
+		"0 9 * * 5#2#3",
+		"0 9 * * 5LL",
+		"0 9 * * 5#L",
+		"0 9 1 * 5L", // DOM restriction + extension
+	} {
+		if _, err := parseCronExpr(expr); err == nil {
+			t.Fatalf("%q: expected error", expr)
+		}
+	}
+}
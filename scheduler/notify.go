@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/db/models"
+)
+
+const (
+	notifyTimeout = 15 * time.Second
+
+	// notifyRedeliveryWindow bounds which unnotified runs are redelivered on Start,
+	// so a restart does not replay notifications for long-finished runs.
+	notifyRedeliveryWindow = 1 * time.Hour
+)
+
+// notifyRunFinished invokes Config.OnRunFinished unless the run was already notified,
+// and records notified_at when the callback succeeds.
+func (s *Scheduler) notifyRunFinished(workerID int, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) {
+	if s.cfg.OnRunFinished == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	var current models.CronRun
+	if err := s.db.WithContext(ctx).Select("id", "notified_at").Where("id = ?", run.ID).First(&current).Error; err != nil {
+		s.log.Warn("scheduler_notify_error", "worker", workerID, "run_id", run.ID, "job_id", run.JobID, "error", err.Error())
+		return
+	}
+	if current.NotifiedAt != nil {
+		s.log.Info("scheduler_notify_skipped", "worker", workerID, "run_id", run.ID, "job_id", run.JobID, "reason", "already_notified")
+		return
+	}
+
+	if err := s.cfg.OnRunFinished(ctx, job, run, status, errStr, summary); err != nil {
+		s.log.Warn("scheduler_notify_error", "worker", workerID, "run_id", run.ID, "job_id", run.JobID, "error", err.Error())
+		return
+	}
+	now := time.Now().UTC().Unix()
+	if err := s.db.WithContext(ctx).
+		Model(&models.CronRun{}).
+		Where("id = ? AND notified_at IS NULL", run.ID).
+		Update("notified_at", now).Error; err != nil {
+		s.log.Warn("scheduler_notify_mark_error", "worker", workerID, "run_id", run.ID, "job_id", run.JobID, "error", err.Error())
+	}
+}
+
+// pendingNotifications lists runs that finished within notifyRedeliveryWindow but were
+// never marked notified (e.g. the process stopped between finishing a run and
+// notifying). Skipped runs are never notified.
+func (s *Scheduler) pendingNotifications(ctx context.Context, now int64) []models.CronRun {
+	if s.cfg.OnRunFinished == nil {
+		return nil
+	}
+	since := now - int64(notifyRedeliveryWindow/time.Second)
+	var runs []models.CronRun
+	if err := s.db.WithContext(ctx).
+		Where("status IN ? AND notified_at IS NULL AND finished_at >= ?", []string{StatusSuccess, StatusFailed, StatusCanceled, StatusTimedOut}, since).
+		Order("finished_at asc").
+		Find(&runs).Error; err != nil {
+		s.log.Warn("scheduler_notify_redeliver_error", "error", err.Error())
+		return nil
+	}
+	return runs
+}
+
+// redeliverNotifications notifies runs one by one, stopping early when ctx is done.
+// Each callback may take up to notifyTimeout, so Start runs this in the background.
+func (s *Scheduler) redeliverNotifications(ctx context.Context, runs []models.CronRun) {
+	for _, run := range runs {
+		if ctx.Err() != nil {
+			return
+		}
+		var job models.CronJob
+		if err := s.db.WithContext(ctx).Where("id = ?", run.JobID).First(&job).Error; err != nil {
+			s.log.Warn("scheduler_notify_redeliver_error", "run_id", run.ID, "job_id", run.JobID, "error", err.Error())
+			continue
+		}
+		s.log.Info("scheduler_notify_redeliver", "run_id", run.ID, "job_id", run.JobID, "status", run.Status)
+		s.notifyRunFinished(0, job, run, run.Status, run.Error, run.ResultSummary)
+	}
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/db/models"
+)
+
+const defaultProgressInterval = 10 * time.Second
+
+type progressKey struct{}
+
+// WithProgress returns a context through which a TaskRunner can report interim
+// progress for the current run (see ReportProgress).
+func WithProgress(ctx context.Context, fn func(summary string)) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress records summary as the interim result_summary of the run that ctx
+// belongs to. Writes are throttled to Config.ProgressInterval, and the final summary
+// replaces whatever was reported. It is a no-op outside a scheduled run.
+func ReportProgress(ctx context.Context, summary string) {
+	if ctx == nil {
+		return
+	}
+	if fn, ok := ctx.Value(progressKey{}).(func(string)); ok {
+		fn(summary)
+	}
+}
+
+// progressWriter persists interim summaries for one running run.
+type progressWriter struct {
+	s     *Scheduler
+	runID string
+	now   func() time.Time
+
+	mu        sync.Mutex
+	lastWrite time.Time
+}
+
+func (s *Scheduler) newProgressWriter(runID string) *progressWriter {
+	return &progressWriter{s: s, runID: runID, now: time.Now}
+}
+
+func (p *progressWriter) report(summary string) {
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if !p.lastWrite.IsZero() && now.Sub(p.lastWrite) < p.s.cfg.ProgressInterval {
+		return
+	}
+	p.lastWrite = now
+
+	summary = truncateString(summary, p.s.cfg.MaxSummaryChars)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Only while running, so a late report can't overwrite the final summary.
+	if err := p.s.db.WithContext(ctx).
+		Model(&models.CronRun{}).
+		Where("id = ? AND status = ?", p.runID, StatusRunning).
+		Update("result_summary", summary).Error; err != nil {
+		p.s.log.Warn("scheduler_progress_error", "run_id", p.runID, "error", err.Error())
+	}
+}
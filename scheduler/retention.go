@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/db/models"
+)
+
+// runHistoryPruneInterval is how often the schedule loop applies the cron_runs
+// retention policy.
+const runHistoryPruneInterval = 1 * time.Hour
+
+// terminalStatuses are the run statuses eligible for pruning. Queued and running
+// runs are never removed.
+var terminalStatuses = []string{StatusSuccess, StatusFailed, StatusCanceled, StatusTimedOut, StatusSkipped}
+
+func (s *Scheduler) retentionEnabled() bool {
+	return s.cfg.MaxRunHistoryPerJob > 0 || s.cfg.RunHistoryMaxAge > 0
+}
+
+// maybePruneRunHistory runs pruneRunHistory at most once per runHistoryPruneInterval.
+func (s *Scheduler) maybePruneRunHistory(ctx context.Context, now int64) {
+	if !s.retentionEnabled() {
+		return
+	}
+	if s.lastPruneAt != 0 && now-s.lastPruneAt < int64(runHistoryPruneInterval/time.Second) {
+		return
+	}
+	s.lastPruneAt = now
+	n, err := s.pruneRunHistory(ctx, now)
+	if err != nil {
+		s.log.Warn("scheduler_prune_error", "error", err.Error())
+		return
+	}
+	if n > 0 {
+		s.log.Info("scheduler_runs_pruned", "count", n)
+	}
+}
+
+// pruneRunHistory deletes terminal cron_runs older than Config.RunHistoryMaxAge and,
+// per job, all but the newest Config.MaxRunHistoryPerJob terminal runs.
+// It returns the number of rows deleted.
+func (s *Scheduler) pruneRunHistory(ctx context.Context, now int64) (int64, error) {
+	var deleted int64
+	if s.cfg.RunHistoryMaxAge > 0 {
+		cutoff := now - int64(s.cfg.RunHistoryMaxAge/time.Second)
+		res := s.db.WithContext(ctx).
+			Where("status IN ? AND scheduled_for < ?", terminalStatuses, cutoff).
+			Delete(&models.CronRun{})
+		if res.Error != nil {
+			return deleted, res.Error
+		}
+		deleted += res.RowsAffected
+	}
+
+	keep := s.cfg.MaxRunHistoryPerJob
+	if keep <= 0 {
+		return deleted, nil
+	}
+	var jobIDs []string
+	if err := s.db.WithContext(ctx).
+		Model(&models.CronRun{}).
+		Where("status IN ?", terminalStatuses).
+		Group("job_id").
+		Having("COUNT(*) > ?", keep).
+		Pluck("job_id", &jobIDs).Error; err != nil {
+		return deleted, err
+	}
+	for _, jobID := range jobIDs {
+		var ids []string
+		if err := s.db.WithContext(ctx).
+			Model(&models.CronRun{}).
+			Where("job_id = ? AND status IN ?", jobID, terminalStatuses).
+			Order("scheduled_for desc").
+			Order("id desc").
+			Pluck("id", &ids).Error; err != nil {
+			return deleted, err
+		}
+		if len(ids) <= keep {
+			continue
+		}
+		stale := ids[keep:]
+		for len(stale) > 0 {
+			batch := stale
+			if len(batch) > 500 {
+				batch = batch[:500]
+			}
+			stale = stale[len(batch):]
+			res := s.db.WithContext(ctx).Where("id IN ?", batch).Delete(&models.CronRun{})
+			if res.Error != nil {
+				return deleted, res.Error
+			}
+			deleted += res.RowsAffected
+		}
+	}
+	return deleted, nil
+}
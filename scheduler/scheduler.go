@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -31,24 +32,49 @@ type Config struct {
 	Enabled     bool
 	Concurrency int
 	Tick        time.Duration
+	// MaxClaimsPerWake bounds how many runs a worker executes before yielding back to
+	// its wait loop (0 = drain the queue). Remaining runs are picked up on the next wake.
+	MaxClaimsPerWake int
+	// OverlapIncludesQueued makes overlap_policy=forbid also count runs that are queued
+	// but not started yet, so forbid means "no pending or running duplicate".
+	OverlapIncludesQueued bool
+
+	// Retention for terminal cron_runs (0 = unlimited). Queued/running runs are never
+	// pruned. MaxRunHistoryPerJob keeps the newest N finished runs per job;
+	// RunHistoryMaxAge drops finished runs scheduled longer ago than this.
+	MaxRunHistoryPerJob int
+	RunHistoryMaxAge    time.Duration
 
 	// Max characters stored in cron_runs.error/result_summary (bounded metadata only).
 	MaxErrorChars   int
 	MaxSummaryChars int
+	// ProgressInterval is the minimum time between interim result_summary writes made
+	// through ReportProgress (default 10s).
+	ProgressInterval time.Duration
+
+	// Optional callback invoked after a run has been claimed, just before the task runs.
+	// Errors are logged and do not prevent the run.
+	OnRunStarted func(ctx context.Context, job models.CronJob, run models.CronRun) error
 
 	// Optional callback invoked after a run is finished and persisted.
 	// This can be used to deliver notifications (e.g., Telegram) in higher-level runtimes.
+	// A successful call is recorded in cron_runs.notified_at; on Start, recently finished
+	// runs without it are delivered again, so the callback is at-least-once but never
+	// repeated once it has succeeded.
 	OnRunFinished func(ctx context.Context, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) error
 }
 
 func DefaultConfig() Config {
 	return Config{
-		Enabled:         false,
-		Concurrency:     1,
-		Tick:            1 * time.Second,
-		MaxErrorChars:   2000,
-		MaxSummaryChars: 1000,
-		OnRunFinished:   nil,
+		Enabled:               false,
+		Concurrency:           1,
+		Tick:                  1 * time.Second,
+		MaxErrorChars:         2000,
+		MaxSummaryChars:       1000,
+		ProgressInterval:      defaultProgressInterval,
+		OverlapIncludesQueued: true,
+		OnRunStarted:          nil,
+		OnRunFinished:         nil,
 	}
 }
 
@@ -64,6 +90,18 @@ type Scheduler struct {
 	wg sync.WaitGroup
 
 	wakeCh chan struct{}
+
+	// lastPruneAt is the unix time of the last retention pass (schedule loop only).
+	lastPruneAt int64
+
+	// running holds the cancel func of every in-flight run, keyed by run ID.
+	runningMu sync.Mutex
+	running   map[string]*runningRun
+}
+
+type runningRun struct {
+	cancel   context.CancelFunc
+	canceled bool // set when cancelled by an operator rather than by timeout/shutdown
 }
 
 func New(db *gorm.DB, defaultModel string, runner TaskRunner, cfg Config, log *slog.Logger) (*Scheduler, error) {
@@ -88,6 +126,9 @@ func New(db *gorm.DB, defaultModel string, runner TaskRunner, cfg Config, log *s
 	if cfg.MaxSummaryChars <= 0 {
 		cfg.MaxSummaryChars = 1000
 	}
+	if cfg.ProgressInterval <= 0 {
+		cfg.ProgressInterval = defaultProgressInterval
+	}
 	if log == nil {
 		log = slog.Default()
 	}
@@ -98,6 +139,7 @@ func New(db *gorm.DB, defaultModel string, runner TaskRunner, cfg Config, log *s
 		defaultModel: defaultModel,
 		runner:       runner,
 		wakeCh:       make(chan struct{}, 1),
+		running:      make(map[string]*runningRun),
 	}, nil
 }
 
@@ -108,6 +150,10 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	// Collect undelivered notifications before recovering orphans: runs that were still
+	// running never finished, so they are not owed one. Delivery happens in the
+	// background so slow callbacks don't hold up startup.
+	pending := s.pendingNotifications(ctx, time.Now().UTC().Unix())
 	if err := s.recoverOrphanedRuns(ctx); err != nil {
 		return err
 	}
@@ -115,7 +161,7 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		return err
 	}
 
-	s.log.Info("scheduler_start", "concurrency", s.cfg.Concurrency, "tick_ms", s.cfg.Tick.Milliseconds())
+	s.log.Info("scheduler_start", "concurrency", s.cfg.Concurrency, "tick_ms", s.cfg.Tick.Milliseconds(), "max_claims_per_wake", s.cfg.MaxClaimsPerWake)
 
 	s.wg.Add(1)
 	go func() {
@@ -131,6 +177,14 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		}(i + 1)
 	}
 
+	if len(pending) > 0 {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.redeliverNotifications(ctx, pending)
+		}()
+	}
+
 	// Kick workers to process any pre-existing queued runs on startup.
 	s.wakeWorkers()
 	return nil
@@ -181,6 +235,7 @@ func (s *Scheduler) scheduleLoop(ctx context.Context) {
 			if err := s.tick(ctx, now); err != nil {
 				s.log.Warn("scheduler_tick_error", "error", err.Error())
 			}
+			s.maybePruneRunHistory(ctx, now)
 		}
 	}
 }
@@ -281,8 +336,12 @@ func (s *Scheduler) enqueueJobIfDue(ctx context.Context, jobID string, now int64
 			}
 		}
 
-		var runningCount int64
-		if err := tx.Model(&models.CronRun{}).Where("job_id = ? AND status = ?", job.ID, StatusRunning).Count(&runningCount).Error; err != nil {
+		activeStatuses := []string{StatusRunning}
+		if s.cfg.OverlapIncludesQueued {
+			activeStatuses = append(activeStatuses, StatusQueued)
+		}
+		var activeCount int64
+		if err := tx.Model(&models.CronRun{}).Where("job_id = ? AND status IN ?", job.ID, activeStatuses).Count(&activeCount).Error; err != nil {
 			return err
 		}
 
@@ -291,8 +350,11 @@ func (s *Scheduler) enqueueJobIfDue(ctx context.Context, jobID string, now int64
 			policy = overlapForbid
 		}
 
-		if runningCount > 0 && policy == overlapForbid {
+		if activeCount > 0 && policy == overlapForbid {
 			msg := "overlap_forbid: prior run still running"
+			if s.cfg.OverlapIncludesQueued {
+				msg = "overlap_forbid: prior run still queued or running"
+			}
 			s.log.Info("scheduler_overlap_forbid", "job_id", job.ID, "scheduled_for", scheduledFor)
 			run := models.CronRun{
 				JobID:        job.ID,
@@ -344,19 +406,34 @@ func (s *Scheduler) workerLoop(ctx context.Context, workerID int) {
 		case <-time.After(idleWait):
 		}
 
-		for {
-			run, ok, err := s.claimNextQueuedRun(ctx)
-			if err != nil {
-				s.log.Warn("scheduler_claim_error", "worker", workerID, "error", err.Error())
-				break
-			}
-			if !ok {
-				break
-			}
+		if _, more := s.processQueued(ctx, workerID); more {
+			// Budget exhausted with work possibly left: yield, then come straight back.
+			s.wakeWorkers()
+		}
+	}
+}
 
-			if err := s.executeRun(ctx, workerID, *run); err != nil {
-				s.log.Warn("scheduler_run_error", "worker", workerID, "run_id", run.ID, "job_id", run.JobID, "error", err.Error())
-			}
+// processQueued claims and executes queued runs until none remain or the per-wake
+// budget (Config.MaxClaimsPerWake) is used up. more reports whether it stopped
+// because of the budget.
+func (s *Scheduler) processQueued(ctx context.Context, workerID int) (processed int, more bool) {
+	budget := s.cfg.MaxClaimsPerWake
+	for {
+		if budget > 0 && processed >= budget {
+			return processed, true
+		}
+		run, ok, err := s.claimNextQueuedRun(ctx)
+		if err != nil {
+			s.log.Warn("scheduler_claim_error", "worker", workerID, "error", err.Error())
+			return processed, false
+		}
+		if !ok {
+			return processed, false
+		}
+		processed++
+
+		if err := s.executeRun(ctx, workerID, *run); err != nil {
+			s.log.Warn("scheduler_run_error", "worker", workerID, "run_id", run.ID, "job_id", run.JobID, "error", err.Error())
 		}
 	}
 }
@@ -417,16 +494,32 @@ func (s *Scheduler) executeRun(ctx context.Context, workerID int, run models.Cro
 	if job.NotifyTelegramChatID != nil && *job.NotifyTelegramChatID != 0 {
 		meta["telegram_chat_id"] = *job.NotifyTelegramChatID
 	}
+	if params, err := jobParams(job); err != nil {
+		s.log.Warn("scheduler_job_params_invalid", "worker", workerID, "run_id", run.ID, "job_id", run.JobID, "error", err.Error())
+	} else if len(params) > 0 {
+		meta["params"] = params
+	}
 
 	runCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
+	rr := s.trackRunning(run.ID, cancel)
+	defer s.untrackRunning(run.ID)
 
 	s.log.Info("scheduler_run_start", "worker", workerID, "run_id", run.ID, "job_id", run.JobID, "scheduled_for", run.ScheduledFor)
-	summary, runErr := s.runner(runCtx, job.Task, model, meta)
+	if s.cfg.OnRunStarted != nil {
+		if err := s.cfg.OnRunStarted(ctx, job, run); err != nil {
+			s.log.Warn("scheduler_run_started_hook_error", "worker", workerID, "run_id", run.ID, "job_id", run.JobID, "error", err.Error())
+		}
+	}
+	summary, runErr := s.runner(WithProgress(runCtx, s.newProgressWriter(run.ID).report), job.Task, model, meta)
 
 	status := StatusFailed
 	var errStr *string
-	if runErr == nil {
+	if s.wasCanceled(rr) {
+		status = StatusCanceled
+		msg := "canceled by operator"
+		errStr = &msg
+	} else if runErr == nil {
 		status = StatusSuccess
 	} else if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
 		status = StatusTimedOut
@@ -455,14 +548,50 @@ func (s *Scheduler) executeRun(ctx context.Context, workerID int, run models.Cro
 		return err
 	}
 
-	if s.cfg.OnRunFinished != nil {
-		notifyCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		defer cancel()
-		if err := s.cfg.OnRunFinished(notifyCtx, job, run, status, errStr, summary); err != nil {
-			s.log.Warn("scheduler_notify_error", "worker", workerID, "run_id", run.ID, "job_id", run.JobID, "error", err.Error())
+	s.notifyRunFinished(workerID, job, run, status, errStr, summary)
+	return nil
+}
+
+// CancelAllRunning cancels every in-flight run. Each one is recorded as
+// StatusCanceled when its runner returns; queued runs are left queued.
+// It returns the number of runs cancelled.
+func (s *Scheduler) CancelAllRunning(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.runningMu.Lock()
+	n := 0
+	for runID, rr := range s.running {
+		if rr.canceled {
+			continue
 		}
+		rr.canceled = true
+		rr.cancel()
+		n++
+		s.log.Warn("scheduler_run_cancel", "run_id", runID)
 	}
-	return nil
+	s.runningMu.Unlock()
+	return n, nil
+}
+
+func (s *Scheduler) trackRunning(runID string, cancel context.CancelFunc) *runningRun {
+	rr := &runningRun{cancel: cancel}
+	s.runningMu.Lock()
+	s.running[runID] = rr
+	s.runningMu.Unlock()
+	return rr
+}
+
+func (s *Scheduler) untrackRunning(runID string) {
+	s.runningMu.Lock()
+	delete(s.running, runID)
+	s.runningMu.Unlock()
+}
+
+func (s *Scheduler) wasCanceled(rr *runningRun) bool {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+	return rr.canceled
 }
 
 func (s *Scheduler) finishRun(runID string, status string, errStr *string, summary *string) error {
@@ -480,6 +609,18 @@ func (s *Scheduler) finishRun(runID string, status string, errStr *string, summa
 		}).Error
 }
 
+// jobParams decodes CronJob.Params. An unset or empty value yields nil.
+func jobParams(job models.CronJob) (map[string]any, error) {
+	if job.Params == nil || strings.TrimSpace(*job.Params) == "" {
+		return nil, nil
+	}
+	var params map[string]any
+	if err := json.Unmarshal([]byte(*job.Params), &params); err != nil {
+		return nil, fmt.Errorf("decode params: %w", err)
+	}
+	return params, nil
+}
+
 func truncateString(s string, max int) string {
 	if max <= 0 || len(s) <= max {
 		return s
@@ -0,0 +1,547 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/db"
+	"github.com/quailyquaily/mistermorph/db/models"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := db.DefaultConfig()
+	cfg.DSN = filepath.Join(t.TempDir(), "sched.sqlite")
+	gdb, err := db.Open(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(gdb); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := gdb.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return gdb
+}
+
+func queueTestRuns(t *testing.T, gdb *gorm.DB, n int) {
+	t.Helper()
+	interval := int64(60)
+	job := models.CronJob{ID: "job1", Name: "backlog", Task: "do it", IntervalSeconds: &interval, OverlapPolicy: "queue"}
+	if err := gdb.Create(&job).Error; err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		run := models.CronRun{ID: fmt.Sprintf("run%d", i), JobID: job.ID, Status: StatusQueued, ScheduledFor: int64(1000 + i)}
+		if err := gdb.Create(&run).Error; err != nil {
+			t.Fatalf("create run: %v", err)
+		}
+	}
+}
+
+func TestProcessQueued_RespectsClaimBudget(t *testing.T) {
+	cases := []struct {
+		name   string
+		budget int
+		queued int
+		want   []int // runs processed per wake
+	}{
+		{name: "unlimited_drains", budget: 0, queued: 5, want: []int{5, 0}},
+		{name: "budget_2", budget: 2, queued: 5, want: []int{2, 2, 1, 0}},
+		{name: "budget_equals_backlog", budget: 3, queued: 3, want: []int{3, 0}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gdb := openTestDB(t)
+			queueTestRuns(t, gdb, tc.queued)
+
+			executed := 0
+			runner := func(ctx context.Context, task string, model string, meta map[string]any) (*string, error) {
+				executed++
+				return nil, nil
+			}
+			cfg := DefaultConfig()
+			cfg.MaxClaimsPerWake = tc.budget
+			s, err := New(gdb, "m", runner, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			for i, want := range tc.want {
+				before := executed
+				got, more := s.processQueued(context.Background(), 1)
+				if got != want || executed-before != want {
+					t.Fatalf("wake %d: processed %d (runner calls %d), want %d", i, got, executed-before, want)
+				}
+				if tc.budget > 0 && got > tc.budget {
+					t.Fatalf("wake %d: processed %d, exceeds budget %d", i, got, tc.budget)
+				}
+				if wantMore := tc.budget > 0 && got == tc.budget; more != wantMore {
+					t.Fatalf("wake %d: more = %v, want %v", i, more, wantMore)
+				}
+			}
+
+			var remaining int64
+			gdb.Model(&models.CronRun{}).Where("status = ?", StatusQueued).Count(&remaining)
+			if remaining != 0 || executed != tc.queued {
+				t.Fatalf("remaining queued = %d, executed = %d", remaining, executed)
+			}
+		})
+	}
+}
+
+func TestCancelAllRunning(t *testing.T) {
+	gdb := openTestDB(t)
+	queueTestRuns(t, gdb, 2)
+
+	started := make(chan struct{})
+	runner := func(ctx context.Context, task string, model string, meta map[string]any) (*string, error) {
+		close(started)
+		<-ctx.Done()
+		// Runners that swallow cancellation must still be recorded as canceled.
+		return nil, nil
+	}
+	cfg := DefaultConfig()
+	cfg.MaxClaimsPerWake = 1
+	s, err := New(gdb, "m", runner, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.processQueued(context.Background(), 1)
+	}()
+	<-started
+
+	n, err := s.CancelAllRunning(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("CancelAllRunning = %d, %v; want 1", n, err)
+	}
+	<-done
+
+	if n, _ := s.CancelAllRunning(context.Background()); n != 0 {
+		t.Fatalf("second CancelAllRunning = %d, want 0", n)
+	}
+
+	var runs []models.CronRun
+	if err := gdb.Order("scheduled_for asc").Find(&runs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("runs = %d", len(runs))
+	}
+	if runs[0].Status != StatusCanceled || runs[0].Error == nil || *runs[0].Error != "canceled by operator" {
+		t.Fatalf("running run = %+v, want canceled", runs[0])
+	}
+	if runs[1].Status != StatusQueued {
+		t.Fatalf("queued run status = %q, want queued", runs[1].Status)
+	}
+}
+
+func TestEnqueueJobIfDue_ForbidCountsQueuedRuns(t *testing.T) {
+	cases := []struct {
+		name           string
+		includesQueued bool
+		priorStatus    string
+		wantQueued     bool
+	}{
+		{name: "queued_prior_skips", includesQueued: true, priorStatus: StatusQueued, wantQueued: false},
+		{name: "running_prior_skips", includesQueued: true, priorStatus: StatusRunning, wantQueued: false},
+		{name: "queued_prior_ignored_when_disabled", includesQueued: false, priorStatus: StatusQueued, wantQueued: true},
+		{name: "finished_prior_enqueues", includesQueued: true, priorStatus: StatusSuccess, wantQueued: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gdb := openTestDB(t)
+			interval := int64(60)
+			due := int64(1000)
+			job := models.CronJob{ID: "job1", Name: "forbid", Task: "do it", Enabled: true, IntervalSeconds: &interval, OverlapPolicy: "forbid", NextRunAt: &due}
+			if err := gdb.Create(&job).Error; err != nil {
+				t.Fatalf("create job: %v", err)
+			}
+			prior := models.CronRun{ID: "prior", JobID: job.ID, Status: tc.priorStatus, ScheduledFor: 940}
+			if err := gdb.Create(&prior).Error; err != nil {
+				t.Fatalf("create run: %v", err)
+			}
+
+			cfg := DefaultConfig()
+			cfg.OverlapIncludesQueued = tc.includesQueued
+			s, err := New(gdb, "m", func(context.Context, string, string, map[string]any) (*string, error) { return nil, nil }, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			queued, err := s.enqueueJobIfDue(context.Background(), job.ID, due)
+			if err != nil {
+				t.Fatalf("enqueueJobIfDue: %v", err)
+			}
+			if queued != tc.wantQueued {
+				t.Fatalf("queued = %v, want %v", queued, tc.wantQueued)
+			}
+			var latest models.CronRun
+			if err := gdb.Where("job_id = ? AND id <> ?", job.ID, "prior").First(&latest).Error; err != nil {
+				t.Fatalf("load new run: %v", err)
+			}
+			wantStatus := StatusQueued
+			if !tc.wantQueued {
+				wantStatus = StatusSkipped
+			}
+			if latest.Status != wantStatus || latest.ScheduledFor != due {
+				t.Fatalf("new run = %+v, want status %s", latest, wantStatus)
+			}
+		})
+	}
+}
+
+func TestPruneRunHistory(t *testing.T) {
+	const now = int64(100_000)
+	type seedRun struct {
+		id     string
+		job    string
+		status string
+		at     int64
+	}
+	seed := []seedRun{
+		{"a1", "jobA", StatusSuccess, now - 10},
+		{"a2", "jobA", StatusFailed, now - 20},
+		{"a3", "jobA", StatusSuccess, now - 30},
+		{"a4", "jobA", StatusSkipped, now - 40},
+		{"a5", "jobA", StatusQueued, now - 50},
+		{"a6", "jobA", StatusRunning, now - 60},
+		{"b1", "jobB", StatusSuccess, now - 10},
+		{"b2", "jobB", StatusTimedOut, now - 5000},
+		{"b3", "jobB", StatusQueued, now - 9000},
+	}
+	cases := []struct {
+		name   string
+		keep   int
+		maxAge time.Duration
+		want   []string
+	}{
+		{name: "disabled", want: []string{"a1", "a2", "a3", "a4", "a5", "a6", "b1", "b2", "b3"}},
+		{name: "keep_newest_2", keep: 2, want: []string{"a1", "a2", "a5", "a6", "b1", "b2", "b3"}},
+		{name: "max_age", maxAge: time.Hour, want: []string{"a1", "a2", "a3", "a4", "a5", "a6", "b1", "b3"}},
+		{name: "both", keep: 1, maxAge: time.Hour, want: []string{"a1", "a5", "a6", "b1", "b3"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gdb := openTestDB(t)
+			for _, id := range []string{"jobA", "jobB"} {
+				interval := int64(60)
+				if err := gdb.Create(&models.CronJob{ID: id, Name: id, Task: "t", IntervalSeconds: &interval}).Error; err != nil {
+					t.Fatalf("create job: %v", err)
+				}
+			}
+			for _, r := range seed {
+				if err := gdb.Create(&models.CronRun{ID: r.id, JobID: r.job, Status: r.status, ScheduledFor: r.at}).Error; err != nil {
+					t.Fatalf("create run: %v", err)
+				}
+			}
+
+			cfg := DefaultConfig()
+			cfg.MaxRunHistoryPerJob = tc.keep
+			cfg.RunHistoryMaxAge = tc.maxAge
+			s, err := New(gdb, "m", func(ctx context.Context, task string, model string, meta map[string]any) (*string, error) {
+				return nil, nil
+			}, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			deleted, err := s.pruneRunHistory(context.Background(), now)
+			if err != nil {
+				t.Fatalf("pruneRunHistory: %v", err)
+			}
+			if want := int64(len(seed) - len(tc.want)); deleted != want {
+				t.Fatalf("deleted = %d, want %d", deleted, want)
+			}
+			var got []string
+			if err := gdb.Model(&models.CronRun{}).Order("id asc").Pluck("id", &got).Error; err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("remaining runs = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExecuteRun_PassesJobParamsInMeta(t *testing.T) {
+	gdb := openTestDB(t)
+	interval := int64(60)
+	params := `{"dataset_id":"ds-42","chat":"ops"}`
+	job := models.CronJob{ID: "job1", Name: "report", Task: "do it", IntervalSeconds: &interval, Params: &params}
+	if err := gdb.Create(&job).Error; err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	if err := gdb.Create(&models.CronRun{ID: "run1", JobID: job.ID, Status: StatusQueued, ScheduledFor: 1000}).Error; err != nil {
+		t.Fatalf("create run: %v", err)
+	}
+
+	var gotMeta map[string]any
+	runner := func(ctx context.Context, task string, model string, meta map[string]any) (*string, error) {
+		gotMeta = meta
+		return nil, nil
+	}
+	s, err := New(gdb, "m", runner, DefaultConfig(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if n, _ := s.processQueued(context.Background(), 1); n != 1 {
+		t.Fatalf("processed %d runs, want 1", n)
+	}
+	got, ok := gotMeta["params"].(map[string]any)
+	if !ok || got["dataset_id"] != "ds-42" || got["chat"] != "ops" {
+		t.Fatalf("meta = %#v", gotMeta)
+	}
+	if gotMeta["trigger"] != "cron" || gotMeta["cron_job_id"] != "job1" {
+		t.Fatalf("meta lost built-in keys: %#v", gotMeta)
+	}
+}
+
+func TestNotifications_NotRepeatedAfterRestart(t *testing.T) {
+	gdb := openTestDB(t)
+	queueTestRuns(t, gdb, 1)
+
+	var notified []string
+	cfg := DefaultConfig()
+	cfg.OnRunFinished = func(ctx context.Context, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) error {
+		notified = append(notified, run.ID)
+		return nil
+	}
+	newScheduler := func() *Scheduler {
+		s, err := New(gdb, "m", func(context.Context, string, string, map[string]any) (*string, error) { return nil, nil }, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		return s
+	}
+
+	s := newScheduler()
+	if n, _ := s.processQueued(context.Background(), 1); n != 1 {
+		t.Fatalf("processed %d runs, want 1", n)
+	}
+	var run models.CronRun
+	if err := gdb.Where("id = ?", "run0").First(&run).Error; err != nil {
+		t.Fatal(err)
+	}
+	if run.NotifiedAt == nil || strings.Join(notified, ",") != "run0" {
+		t.Fatalf("after run: notified_at = %v, notified = %v", run.NotifiedAt, notified)
+	}
+
+	// A run that finished but whose notification never went out (crash before notify),
+	// and one that finished too long ago to be redelivered.
+	now := time.Now().UTC().Unix()
+	recent, stale := now-60, now-int64(2*notifyRedeliveryWindow/time.Second)
+	for _, r := range []models.CronRun{
+		{ID: "pending", JobID: "job1", Status: StatusFailed, ScheduledFor: 2000, FinishedAt: &recent},
+		{ID: "stale", JobID: "job1", Status: StatusSuccess, ScheduledFor: 1500, FinishedAt: &stale},
+		{ID: "skipped", JobID: "job1", Status: StatusSkipped, ScheduledFor: 2500, FinishedAt: &recent},
+	} {
+		if err := gdb.Create(&r).Error; err != nil {
+			t.Fatalf("create run: %v", err)
+		}
+	}
+
+	// Simulated restart: a fresh scheduler redelivers only the pending run.
+	notified = nil
+	s = newScheduler()
+	s.redeliverNotifications(context.Background(), s.pendingNotifications(context.Background(), now))
+	if strings.Join(notified, ",") != "pending" {
+		t.Fatalf("redelivered = %v, want [pending]", notified)
+	}
+
+	// A second restart finds nothing left to deliver.
+	notified = nil
+	s = newScheduler()
+	s.redeliverNotifications(context.Background(), s.pendingNotifications(context.Background(), now))
+	if len(notified) != 0 {
+		t.Fatalf("second restart redelivered %v", notified)
+	}
+}
+
+func TestNotifications_FailedCallbackIsRetriedOnRestart(t *testing.T) {
+	gdb := openTestDB(t)
+	queueTestRuns(t, gdb, 1)
+
+	calls := 0
+	cfg := DefaultConfig()
+	cfg.OnRunFinished = func(ctx context.Context, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) error {
+		calls++
+		if calls == 1 {
+			return fmt.Errorf("telegram unavailable")
+		}
+		return nil
+	}
+	s, err := New(gdb, "m", func(context.Context, string, string, map[string]any) (*string, error) { return nil, nil }, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.processQueued(context.Background(), 1)
+
+	var run models.CronRun
+	gdb.Where("id = ?", "run0").First(&run)
+	if run.NotifiedAt != nil {
+		t.Fatalf("notified_at set after failed callback")
+	}
+	s.redeliverNotifications(context.Background(), s.pendingNotifications(context.Background(), time.Now().UTC().Unix()))
+	gdb.Where("id = ?", "run0").First(&run)
+	if calls != 2 || run.NotifiedAt == nil {
+		t.Fatalf("calls = %d, notified_at = %v", calls, run.NotifiedAt)
+	}
+}
+
+func TestAutoMigrate_BackfillsNotifiedAt(t *testing.T) {
+	gdb := openTestDB(t)
+	queueTestRuns(t, gdb, 2)
+	finished := time.Now().UTC().Unix() - 60
+	if err := gdb.Model(&models.CronRun{}).Where("id = ?", "run0").
+		Updates(map[string]any{"status": StatusSuccess, "finished_at": finished}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a database created before cron_runs.notified_at existed.
+	if err := gdb.Migrator().DropColumn(&models.CronRun{}, "NotifiedAt"); err != nil {
+		t.Fatalf("drop column: %v", err)
+	}
+	if err := db.AutoMigrate(gdb); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	var runs []models.CronRun
+	if err := gdb.Order("id asc").Find(&runs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if runs[0].NotifiedAt == nil || *runs[0].NotifiedAt != finished {
+		t.Fatalf("finished run notified_at = %v, want %d", runs[0].NotifiedAt, finished)
+	}
+	if runs[1].NotifiedAt != nil {
+		t.Fatalf("queued run notified_at = %v, want nil", *runs[1].NotifiedAt)
+	}
+
+	cfg := DefaultConfig()
+	cfg.OnRunFinished = func(ctx context.Context, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) error {
+		return nil
+	}
+	s, err := New(gdb, "m", func(context.Context, string, string, map[string]any) (*string, error) { return nil, nil }, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if pending := s.pendingNotifications(context.Background(), time.Now().UTC().Unix()); len(pending) != 0 {
+		t.Fatalf("pending after upgrade = %+v, want none", pending)
+	}
+}
+
+func TestStart_RedeliversNotificationsInBackground(t *testing.T) {
+	gdb := openTestDB(t)
+	queueTestRuns(t, gdb, 1)
+	finished := time.Now().UTC().Unix() - 60
+	if err := gdb.Model(&models.CronRun{}).Where("id = ?", "run0").
+		Updates(map[string]any{"status": StatusFailed, "finished_at": finished}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	delivered := make(chan string, 1)
+	cfg := DefaultConfig()
+	cfg.Enabled = true
+	cfg.OnRunFinished = func(ctx context.Context, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) error {
+		<-release
+		select {
+		case delivered <- run.ID:
+		default:
+		}
+		return nil
+	}
+	s, err := New(gdb, "m", func(context.Context, string, string, map[string]any) (*string, error) { return nil, nil }, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		s.Wait()
+	}()
+
+	started := make(chan error, 1)
+	go func() { started <- s.Start(ctx) }()
+	select {
+	case err := <-started:
+		if err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		close(release)
+		t.Fatalf("Start blocked on notification redelivery")
+	}
+
+	close(release)
+	select {
+	case id := <-delivered:
+		if id != "run0" {
+			t.Fatalf("redelivered %q, want run0", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("pending notification was not redelivered")
+	}
+}
+
+func TestReportProgress_InterimThenFinal(t *testing.T) {
+	gdb := openTestDB(t)
+	queueTestRuns(t, gdb, 1)
+
+	loadSummary := func() string {
+		t.Helper()
+		var run models.CronRun
+		if err := gdb.Where("id = ?", "run0").First(&run).Error; err != nil {
+			t.Fatal(err)
+		}
+		if run.ResultSummary == nil {
+			return ""
+		}
+		return *run.ResultSummary
+	}
+
+	var mid []string
+	runner := func(ctx context.Context, task string, model string, meta map[string]any) (*string, error) {
+		ReportProgress(ctx, "step 1 done")
+		mid = append(mid, loadSummary())
+		ReportProgress(ctx, "step 2 done") // within the interval: dropped
+		mid = append(mid, loadSummary())
+		final := "all steps done"
+		return &final, nil
+	}
+	cfg := DefaultConfig()
+	cfg.ProgressInterval = time.Hour
+	s, err := New(gdb, "m", runner, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.processQueued(context.Background(), 1)
+
+	if strings.Join(mid, "|") != "step 1 done|step 1 done" {
+		t.Fatalf("interim summaries = %q", mid)
+	}
+	if got := loadSummary(); got != "all steps done" {
+		t.Fatalf("final summary = %q", got)
+	}
+
+	// Reports after the run finished never overwrite the final summary.
+	p := s.newProgressWriter("run0")
+	p.report("late")
+	if got := loadSummary(); got != "all steps done" {
+		t.Fatalf("summary after late report = %q", got)
+	}
+
+	ReportProgress(context.Background(), "outside a run") // no-op
+}
@@ -0,0 +1,86 @@
+package skills
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache keeps loaded skills in memory and reloads a SKILL.md only when its
+// modtime or size changes, so edits take effect on the next run without a restart.
+// If a changed file can't be read or has malformed frontmatter, the last good
+// version is served instead. Safe for concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	modTime  time.Time
+	size     int64
+	maxBytes int64
+	skill    Skill
+}
+
+func NewCache() *Cache {
+	return &Cache{entries: make(map[string]cacheEntry)}
+}
+
+// Load returns the skill with Contents populated, like Load, but served from cache when unchanged.
+func (c *Cache) Load(skill Skill, maxBytes int64) (Skill, error) {
+	key := skill.SkillMD
+
+	c.mu.Lock()
+	prev, hasPrev := c.entries[key]
+	c.mu.Unlock()
+
+	lastGood := func() Skill {
+		out := skill
+		out.Contents = prev.skill.Contents
+		out.AuthProfiles = append([]string(nil), prev.skill.AuthProfiles...)
+		return out
+	}
+
+	info, err := os.Stat(key)
+	if err != nil {
+		if hasPrev {
+			return lastGood(), nil
+		}
+		return Skill{}, err
+	}
+	if hasPrev && prev.maxBytes == maxBytes && prev.size == info.Size() && prev.modTime.Equal(info.ModTime()) {
+		return lastGood(), nil
+	}
+
+	loaded, err := Load(skill, maxBytes)
+	if err == nil && hasFrontmatterStart(loaded.Contents) {
+		if _, ok := ParseFrontmatter(loaded.Contents); !ok {
+			err = errMalformedFrontmatter
+		}
+	}
+	if err != nil {
+		if hasPrev {
+			return lastGood(), nil
+		}
+		if errors.Is(err, errMalformedFrontmatter) {
+			// Nothing better to fall back to; serve the file as-is.
+			return loaded, nil
+		}
+		return Skill{}, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{modTime: info.ModTime(), size: info.Size(), maxBytes: maxBytes, skill: loaded}
+	c.mu.Unlock()
+	return loaded, nil
+}
+
+var errMalformedFrontmatter = errors.New("malformed SKILL.md frontmatter")
+
+func hasFrontmatterStart(contents string) bool {
+	sc := bufio.NewScanner(strings.NewReader(contents))
+	return sc.Scan() && strings.TrimSpace(sc.Text()) == "---"
+}
@@ -0,0 +1,95 @@
+package skills
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeSkillFile(t *testing.T, path, contents string, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCache_ReloadsEditedSkill(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "greeter")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "SKILL.md")
+	base := time.Now().Add(-time.Hour)
+	writeSkillFile(t, path, "---\nauth_profiles: [\"a\"]\n---\nsay hello\n", base)
+
+	c := NewCache()
+	s := Skill{ID: "greeter", Name: "greeter", Dir: dir, SkillMD: path}
+
+	got, err := c.Load(s, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got.Contents, "say hello") || len(got.AuthProfiles) != 1 {
+		t.Fatalf("first load = %+v", got)
+	}
+
+	// Edit the skill; the next run must see the new prompt.
+	writeSkillFile(t, path, "---\nauth_profiles: [\"b\"]\n---\nsay goodbye\n", base.Add(time.Minute))
+	got, err = c.Load(s, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got.Contents, "say goodbye") || got.AuthProfiles[0] != "b" {
+		t.Fatalf("after edit = %+v", got)
+	}
+
+	// A broken edit falls back to the last good version.
+	writeSkillFile(t, path, "---\nauth_profiles: [unterminated\n---\nbroken\n", base.Add(2*time.Minute))
+	got, err = c.Load(s, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got.Contents, "say goodbye") {
+		t.Fatalf("expected last-good contents, got %q", got.Contents)
+	}
+
+	// Deleting the file also serves last-good.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = c.Load(s, 0); err != nil || !strings.Contains(got.Contents, "say goodbye") {
+		t.Fatalf("after delete: %+v, %v", got, err)
+	}
+}
+
+func TestCache_MissingFileWithoutHistoryErrors(t *testing.T) {
+	c := NewCache()
+	if _, err := c.Load(Skill{SkillMD: filepath.Join(t.TempDir(), "SKILL.md")}, 0); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestCache_ConcurrentLoads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "SKILL.md")
+	writeSkillFile(t, path, "hello\n", time.Now())
+	c := NewCache()
+	s := Skill{ID: "x", SkillMD: path}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := c.Load(s, 0); err != nil || got.Contents != "hello\n" {
+				t.Errorf("Load = %q, %v", got.Contents, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
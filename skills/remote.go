@@ -0,0 +1,274 @@
+package skills
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RemoteSource is a shared skill library served over HTTP(S) as a JSON manifest:
+//
+//	{"skills": [{"id": "pdf-report", "content": "<SKILL.md>", "sha256": "<hex, optional>"}]}
+//
+// SHA256, when set, pins the whole manifest body.
+type RemoteSource struct {
+	URL    string
+	SHA256 string
+}
+
+type RemoteOptions struct {
+	// CacheDir holds one subdirectory per source; each becomes a regular skills root.
+	CacheDir string
+	// AllowInsecure permits plain http:// sources.
+	AllowInsecure bool
+	// TTL skips refetching while the cached copy, or the last failed attempt, is younger
+	// than this (0 always refetches).
+	TTL        time.Duration
+	HTTPClient *http.Client
+	// MaxBytes caps the manifest size (default 4 MiB).
+	MaxBytes int64
+}
+
+type remoteManifest struct {
+	Skills []remoteSkill `json:"skills"`
+}
+
+type remoteSkill struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	SHA256  string `json:"sha256,omitempty"`
+}
+
+var remoteSkillIDRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+const (
+	remoteFetchedMarker = ".fetched"
+	remoteFailedSuffix  = ".failed"
+	remoteVersionInfix  = ".v"
+)
+
+// SyncRemote fetches src into opts.CacheDir and returns the directory to use as a skills root.
+// On fetch or integrity failure it returns the previously cached directory (if any) together
+// with the error, so callers can keep serving the last good copy. Syncs of the same source
+// are serialized, and a failed fetch is not retried until opts.TTL has passed.
+func SyncRemote(ctx context.Context, src RemoteSource, opts RemoteOptions) (string, error) {
+	rawURL := strings.TrimSpace(src.URL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid remote skills url: %q", rawURL)
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !opts.AllowInsecure {
+			return "", fmt.Errorf("refusing non-https remote skills source: %s", rawURL)
+		}
+	default:
+		return "", fmt.Errorf("unsupported remote skills url scheme: %q", u.Scheme)
+	}
+	cacheRoot := strings.TrimSpace(opts.CacheDir)
+	if cacheRoot == "" {
+		return "", fmt.Errorf("remote skills cache dir is not configured")
+	}
+
+	sum := sha256.Sum256([]byte(rawURL))
+	link := filepath.Join(cacheRoot, hex.EncodeToString(sum[:8]))
+	defer lockRemoteSync(link)()
+
+	cached := currentRemoteDir(link)
+	if cached != "" && opts.TTL > 0 {
+		if st, err := os.Stat(filepath.Join(cached, remoteFetchedMarker)); err == nil && time.Since(st.ModTime()) < opts.TTL {
+			return cached, nil
+		}
+	}
+	// Back off after a failed fetch so an unreachable source doesn't stall every run.
+	failedMarker := link + remoteFailedSuffix
+	if opts.TTL > 0 {
+		if st, err := os.Stat(failedMarker); err == nil && time.Since(st.ModTime()) < opts.TTL {
+			if cached != "" {
+				return cached, nil
+			}
+			return "", fmt.Errorf("remote skills source failed %s ago; next attempt after %s", time.Since(st.ModTime()).Round(time.Second), opts.TTL)
+		}
+	}
+
+	manifest, err := fetchRemoteManifest(ctx, rawURL, src.SHA256, opts)
+	if err != nil {
+		_ = os.MkdirAll(cacheRoot, 0o700)
+		_ = os.WriteFile(failedMarker, nil, 0o600)
+		return cached, err
+	}
+	dir, err := writeRemoteSkills(cacheRoot, link, manifest)
+	if err != nil {
+		return cached, err
+	}
+	_ = os.Remove(failedMarker)
+	return dir, nil
+}
+
+var remoteSyncLocks sync.Map // cache link path -> *sync.Mutex
+
+// lockRemoteSync serializes syncs of the same source within the process and returns the unlock func.
+func lockRemoteSync(link string) func() {
+	v, _ := remoteSyncLocks.LoadOrStore(link, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// currentRemoteDir resolves the cache link to the version directory it points at,
+// or "" when nothing has been fetched yet.
+func currentRemoteDir(link string) string {
+	fi, err := os.Lstat(link)
+	if err != nil {
+		return ""
+	}
+	dir := link
+	if fi.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(link)
+		if err != nil {
+			return ""
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(link), target)
+		}
+		dir = target
+	}
+	if _, err := os.Stat(filepath.Join(dir, remoteFetchedMarker)); err != nil {
+		return ""
+	}
+	return dir
+}
+
+func fetchRemoteManifest(ctx context.Context, rawURL, wantSHA string, opts RemoteOptions) (remoteManifest, error) {
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 4 << 20
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return remoteManifest{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return remoteManifest{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return remoteManifest{}, fmt.Errorf("remote skills http %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return remoteManifest{}, err
+	}
+	if int64(len(body)) > maxBytes {
+		return remoteManifest{}, fmt.Errorf("remote skills manifest too large (>%d bytes)", maxBytes)
+	}
+	if err := checkSHA256(body, wantSHA); err != nil {
+		return remoteManifest{}, fmt.Errorf("remote skills manifest: %w", err)
+	}
+
+	var m remoteManifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return remoteManifest{}, fmt.Errorf("remote skills manifest: %w", err)
+	}
+	seen := make(map[string]bool, len(m.Skills))
+	for _, s := range m.Skills {
+		if !remoteSkillIDRe.MatchString(s.ID) {
+			return remoteManifest{}, fmt.Errorf("remote skill has invalid id: %q", s.ID)
+		}
+		if seen[strings.ToLower(s.ID)] {
+			return remoteManifest{}, fmt.Errorf("remote skill id is duplicated: %q", s.ID)
+		}
+		seen[strings.ToLower(s.ID)] = true
+		if err := checkSHA256([]byte(s.Content), s.SHA256); err != nil {
+			return remoteManifest{}, fmt.Errorf("remote skill %s: %w", s.ID, err)
+		}
+	}
+	return m, nil
+}
+
+func checkSHA256(data []byte, want string) error {
+	want = strings.ToLower(strings.TrimSpace(want))
+	if want == "" {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("sha256 mismatch: got %s, want %s", got, want)
+	}
+	return nil
+}
+
+// writeRemoteSkills materializes the manifest in a fresh version directory and atomically
+// repoints the cache link at it, so readers always see either the old or the new library.
+// The previous version is kept for readers that resolved it just before the swap.
+func writeRemoteSkills(cacheRoot, link string, m remoteManifest) (string, error) {
+	if err := os.MkdirAll(cacheRoot, 0o700); err != nil {
+		return "", err
+	}
+	base := filepath.Base(link)
+	dir, err := os.MkdirTemp(cacheRoot, base+remoteVersionInfix)
+	if err != nil {
+		return "", err
+	}
+	swapped := false
+	defer func() {
+		if !swapped {
+			_ = os.RemoveAll(dir)
+		}
+	}()
+
+	for _, s := range m.Skills {
+		skillDir := filepath.Join(dir, s.ID)
+		if err := os.MkdirAll(skillDir, 0o700); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(s.Content), 0o600); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, remoteFetchedMarker), nil, 0o600); err != nil {
+		return "", err
+	}
+
+	prev := currentRemoteDir(link)
+	tmpLink := dir + ".link"
+	if err := os.Symlink(filepath.Base(dir), tmpLink); err != nil {
+		return "", err
+	}
+	if fi, err := os.Lstat(link); err == nil && fi.IsDir() {
+		// Caches written before versioned directories hold a plain directory here.
+		_ = os.RemoveAll(link)
+		prev = ""
+	}
+	if err := os.Rename(tmpLink, link); err != nil {
+		_ = os.Remove(tmpLink)
+		return "", err
+	}
+	swapped = true
+
+	old, _ := filepath.Glob(filepath.Join(cacheRoot, base+remoteVersionInfix+"*"))
+	for _, p := range old {
+		if p != dir && p != prev {
+			_ = os.RemoveAll(p)
+		}
+	}
+	return dir, nil
+}
@@ -0,0 +1,205 @@
+package skills
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestSyncRemote_MergesWithLocalAndFallsBack(t *testing.T) {
+	remoteContent := "# shared remote skill\n"
+	manifest := `{"skills":[{"id":"shared-remote","content":"# shared remote skill\n","sha256":"` + sha256Hex(remoteContent) + `"},` +
+		`{"id":"local-one","content":"# remote copy of local\n"}]}`
+
+	var fail atomic.Bool
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(manifest))
+	}))
+	defer srv.Close()
+
+	local := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(local, "local-one"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(local, "local-one", "SKILL.md"), []byte("# local\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := RemoteOptions{CacheDir: filepath.Join(t.TempDir(), "remote"), HTTPClient: srv.Client()}
+	dir, err := SyncRemote(context.Background(), RemoteSource{URL: srv.URL + "/manifest.json"}, opts)
+	if err != nil {
+		t.Fatalf("SyncRemote: %v", err)
+	}
+
+	list, err := Discover(DiscoverOptions{Roots: []string{local, dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := map[string]Skill{}
+	for _, s := range list {
+		byID[s.ID] = s
+	}
+	if _, ok := byID["shared-remote"]; !ok {
+		t.Fatalf("remote skill not merged: %+v", list)
+	}
+	if s := byID["local-one"]; s.RootDir != local {
+		t.Fatalf("local skill should win on duplicate id, got root %s", s.RootDir)
+	}
+
+	// Fetch failure: keep serving the cached copy and report the error.
+	fail.Store(true)
+	dir2, err := SyncRemote(context.Background(), RemoteSource{URL: srv.URL + "/manifest.json"}, opts)
+	if err == nil {
+		t.Fatal("expected fetch error")
+	}
+	if dir2 != dir {
+		t.Fatalf("expected cached dir %q, got %q", dir, dir2)
+	}
+	if _, err := os.Stat(filepath.Join(dir2, "shared-remote", "SKILL.md")); err != nil {
+		t.Fatalf("cached skill missing: %v", err)
+	}
+
+	// Fetch failure with no cache: no remote root, local skills still discoverable.
+	opts.CacheDir = filepath.Join(t.TempDir(), "empty")
+	dir3, err := SyncRemote(context.Background(), RemoteSource{URL: srv.URL + "/manifest.json"}, opts)
+	if err == nil || dir3 != "" {
+		t.Fatalf("expected error and no dir, got %q, %v", dir3, err)
+	}
+	list, _ = Discover(DiscoverOptions{Roots: []string{local}})
+	if len(list) != 1 || list[0].ID != "local-one" {
+		t.Fatalf("local discovery = %+v", list)
+	}
+}
+
+func TestSyncRemote_Rejections(t *testing.T) {
+	body := `{"skills":[{"id":"x","content":"hi","sha256":"deadbeef"}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	cache := t.TempDir()
+
+	if _, err := SyncRemote(context.Background(), RemoteSource{URL: srv.URL}, RemoteOptions{CacheDir: cache}); err == nil || !strings.Contains(err.Error(), "non-https") {
+		t.Fatalf("expected non-https rejection, got %v", err)
+	}
+
+	opts := RemoteOptions{CacheDir: cache, AllowInsecure: true}
+	if _, err := SyncRemote(context.Background(), RemoteSource{URL: srv.URL}, opts); err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("expected per-skill integrity failure, got %v", err)
+	}
+
+	body = `{"skills":[{"id":"x","content":"hi"}]}`
+	if _, err := SyncRemote(context.Background(), RemoteSource{URL: srv.URL, SHA256: sha256Hex("other")}, opts); err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("expected manifest integrity failure, got %v", err)
+	}
+	if _, err := SyncRemote(context.Background(), RemoteSource{URL: srv.URL, SHA256: sha256Hex(body)}, opts); err != nil {
+		t.Fatalf("expected pinned manifest to pass, got %v", err)
+	}
+
+	body = `{"skills":[{"id":"../escape","content":"hi"}]}`
+	if _, err := SyncRemote(context.Background(), RemoteSource{URL: srv.URL}, opts); err == nil || !strings.Contains(err.Error(), "invalid id") {
+		t.Fatalf("expected invalid id rejection, got %v", err)
+	}
+}
+
+func TestSyncRemote_BacksOffAfterFailure(t *testing.T) {
+	var hits atomic.Int32
+	var fail atomic.Bool
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if fail.Load() {
+			http.Error(w, "boom", http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"skills":[{"id":"a","content":"# a\n"}]}`))
+	}))
+	defer srv.Close()
+	src := RemoteSource{URL: srv.URL + "/manifest.json"}
+
+	opts := RemoteOptions{CacheDir: t.TempDir(), HTTPClient: srv.Client(), TTL: time.Hour}
+	dir, err := SyncRemote(context.Background(), src, opts)
+	if err != nil {
+		t.Fatalf("SyncRemote: %v", err)
+	}
+	// Expire the cached copy, then take the source down.
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, remoteFetchedMarker), past, past); err != nil {
+		t.Fatal(err)
+	}
+	fail.Store(true)
+	if _, err := SyncRemote(context.Background(), src, opts); err == nil {
+		t.Fatal("expected fetch error")
+	}
+	got, err := SyncRemote(context.Background(), src, opts)
+	if err != nil || got != dir {
+		t.Fatalf("expected cached dir %q without error during backoff, got %q, %v", dir, got, err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("hits = %d, want 2 (no refetch during backoff)", n)
+	}
+
+	// Without a cache the backoff still applies and keeps reporting an error.
+	opts.CacheDir = t.TempDir()
+	if _, err := SyncRemote(context.Background(), src, opts); err == nil {
+		t.Fatal("expected fetch error")
+	}
+	if got, err := SyncRemote(context.Background(), src, opts); err == nil || got != "" {
+		t.Fatalf("expected backoff error and no dir, got %q, %v", got, err)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Fatalf("hits = %d, want 3", n)
+	}
+}
+
+func TestSyncRemote_ConcurrentSyncs(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"skills":[{"id":"a","content":"# a\n"}]}`))
+	}))
+	defer srv.Close()
+	src := RemoteSource{URL: srv.URL + "/manifest.json"}
+	opts := RemoteOptions{CacheDir: t.TempDir(), HTTPClient: srv.Client()}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dir, err := SyncRemote(context.Background(), src, opts)
+			if err == nil {
+				_, err = os.Stat(filepath.Join(dir, "a", "SKILL.md"))
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent sync: %v", err)
+		}
+	}
+
+	versions, _ := filepath.Glob(filepath.Join(opts.CacheDir, "*"+remoteVersionInfix+"*"))
+	if len(versions) > 2 {
+		t.Fatalf("expected at most current and previous versions, got %v", versions)
+	}
+}
@@ -0,0 +1,66 @@
+package skills
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Load sources recorded by Usage.
+const (
+	UsageExplicit = "explicit" // requested by config, $SkillName reference or sticky state
+	UsageSelected = "selected" // chosen by smart selection
+)
+
+type UsageStat struct {
+	Loads        int64     `json:"loads"`
+	Explicit     int64     `json:"explicit"`
+	Selected     int64     `json:"selected"`
+	LastLoadedAt time.Time `json:"last_loaded_at"`
+}
+
+// Usage counts how often each skill is loaded into a run. Safe for concurrent use.
+type Usage struct {
+	mu    sync.Mutex
+	stats map[string]*UsageStat
+}
+
+func NewUsage() *Usage {
+	return &Usage{stats: make(map[string]*UsageStat)}
+}
+
+func (u *Usage) RecordLoad(id string, source string) {
+	id = strings.TrimSpace(id)
+	if u == nil || id == "" {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	st := u.stats[id]
+	if st == nil {
+		st = &UsageStat{}
+		u.stats[id] = st
+	}
+	st.Loads++
+	switch source {
+	case UsageExplicit:
+		st.Explicit++
+	case UsageSelected:
+		st.Selected++
+	}
+	st.LastLoadedAt = time.Now().UTC()
+}
+
+// Snapshot returns a copy of the counters keyed by skill id.
+func (u *Usage) Snapshot() map[string]UsageStat {
+	if u == nil {
+		return nil
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	out := make(map[string]UsageStat, len(u.stats))
+	for id, st := range u.stats {
+		out[id] = *st
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+package skills
+
+import "testing"
+
+func TestUsage_RecordAndSnapshot(t *testing.T) {
+	u := NewUsage()
+	u.RecordLoad("a", UsageExplicit)
+	u.RecordLoad("a", UsageSelected)
+	u.RecordLoad("b", UsageSelected)
+	u.RecordLoad(" ", UsageSelected)
+
+	snap := u.Snapshot()
+	if len(snap) != 2 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	if a := snap["a"]; a.Loads != 2 || a.Explicit != 1 || a.Selected != 1 || a.LastLoadedAt.IsZero() {
+		t.Fatalf("a = %+v", a)
+	}
+	if b := snap["b"]; b.Loads != 1 || b.Selected != 1 {
+		t.Fatalf("b = %+v", b)
+	}
+
+	// Snapshots are copies.
+	snap["a"] = UsageStat{}
+	if u.Snapshot()["a"].Loads != 2 {
+		t.Fatal("snapshot mutation leaked into counters")
+	}
+}
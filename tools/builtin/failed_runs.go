@@ -0,0 +1,154 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/db/models"
+	"github.com/quailyquaily/mistermorph/scheduler"
+)
+
+type FailedRunsTool struct {
+	dsn string
+
+	now func() time.Time
+}
+
+func NewFailedRunsTool(dsn string) *FailedRunsTool {
+	return &FailedRunsTool{dsn: strings.TrimSpace(dsn), now: time.Now}
+}
+
+func (t *FailedRunsTool) Name() string { return "failed_runs" }
+func (t *FailedRunsTool) Description() string {
+	return "List cron jobs whose runs failed or timed out within a recent window (UTC), with failure counts and the latest error, most failures first. Use it to alert about jobs that keep failing."
+}
+
+func (t *FailedRunsTool) ParameterSchema() string {
+	return `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "window_hours": { "type": "integer", "description": "Look-back window in hours (default 24, max 720)." },
+    "limit": { "type": "integer", "description": "Max jobs returned (default 20, max 200)." }
+  }
+}`
+}
+
+type failedJobSummary struct {
+	jobID     string
+	failures  int
+	timeouts  int
+	lastAt    int64
+	lastError string
+}
+
+func (t *FailedRunsTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	gdb, err := openJobsDB(ctx, t.dsn)
+	if err != nil {
+		return "", err
+	}
+
+	windowHours := getInt64(params, "window_hours")
+	if windowHours <= 0 {
+		windowHours = 24
+	}
+	if windowHours > 720 {
+		windowHours = 720
+	}
+	limit := int(getInt64(params, "limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+	since := now().Add(-time.Duration(windowHours) * time.Hour).Unix()
+
+	var runs []models.CronRun
+	if err := gdb.WithContext(ctx).
+		Where("status IN ? AND scheduled_for >= ?", []string{scheduler.StatusFailed, scheduler.StatusTimedOut}, since).
+		Order("scheduled_for desc").
+		Find(&runs).Error; err != nil {
+		return "", err
+	}
+
+	byJob := make(map[string]*failedJobSummary)
+	for _, r := range runs {
+		s, ok := byJob[r.JobID]
+		if !ok {
+			// Runs are newest first, so the first one seen carries the latest error.
+			s = &failedJobSummary{jobID: r.JobID, lastAt: r.ScheduledFor}
+			if r.Error != nil {
+				s.lastError = truncate(*r.Error, 300)
+			}
+			byJob[r.JobID] = s
+		}
+		s.failures++
+		if r.Status == scheduler.StatusTimedOut {
+			s.timeouts++
+		}
+	}
+
+	summaries := make([]*failedJobSummary, 0, len(byJob))
+	for _, s := range byJob {
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].failures != summaries[j].failures {
+			return summaries[i].failures > summaries[j].failures
+		}
+		if summaries[i].lastAt != summaries[j].lastAt {
+			return summaries[i].lastAt > summaries[j].lastAt
+		}
+		return summaries[i].jobID < summaries[j].jobID
+	})
+	if len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+
+	names := make(map[string]string, len(summaries))
+	if len(summaries) > 0 {
+		ids := make([]string, 0, len(summaries))
+		for _, s := range summaries {
+			ids = append(ids, s.jobID)
+		}
+		var jobs []models.CronJob
+		if err := gdb.WithContext(ctx).Select("id", "name").Where("id IN ?", ids).Find(&jobs).Error; err != nil {
+			return "", err
+		}
+		for _, j := range jobs {
+			names[j.ID] = j.Name
+		}
+	}
+
+	out := make([]map[string]any, 0, len(summaries))
+	for _, s := range summaries {
+		item := map[string]any{
+			"job_id":             s.jobID,
+			"name":               names[s.jobID],
+			"failures":           s.failures,
+			"timeouts":           s.timeouts,
+			"last_failed_at_utc": time.Unix(s.lastAt, 0).UTC().Format(time.RFC3339),
+		}
+		if s.lastError != "" {
+			item["last_error"] = s.lastError
+		}
+		out = append(out, item)
+	}
+
+	b, _ := json.Marshal(map[string]any{
+		"ok":           true,
+		"window_hours": windowHours,
+		"count":        len(out),
+		"jobs":         out,
+	})
+	return string(b), nil
+}
@@ -0,0 +1,148 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/db/models"
+	"github.com/quailyquaily/mistermorph/scheduler"
+)
+
+func TestFailedRunsTool_GroupsRecentFailures(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "jobs.sqlite")
+	jobs := NewScheduleJobTool(dsn)
+	ctx := context.Background()
+
+	ids := map[string]string{}
+	for _, name := range []string{"flaky", "broken", "healthy", "old"} {
+		out, err := jobs.Execute(ctx, map[string]any{
+			"name":     name,
+			"task":     "do " + name,
+			"schedule": "0 * * * *",
+		})
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		var res struct {
+			JobID string `json:"job_id"`
+		}
+		if err := json.Unmarshal([]byte(out), &res); err != nil || res.JobID == "" {
+			t.Fatalf("create output %q: %v", out, err)
+		}
+		ids[name] = res.JobID
+	}
+
+	now := time.Unix(1_800_000_000, 0)
+	hour := int64(time.Hour / time.Second)
+	seed := []struct {
+		job    string
+		status string
+		ago    int64
+		err    string
+	}{
+		{"flaky", scheduler.StatusFailed, 1, "newest flaky error"},
+		{"flaky", scheduler.StatusSuccess, 2, ""},
+		{"flaky", scheduler.StatusFailed, 5, "older flaky error"},
+		{"broken", scheduler.StatusTimedOut, 2, "timeout"},
+		{"broken", scheduler.StatusFailed, 3, "exit 1"},
+		{"broken", scheduler.StatusFailed, 4, "exit 1"},
+		{"healthy", scheduler.StatusSuccess, 1, ""},
+		{"healthy", scheduler.StatusCanceled, 2, "canceled"},
+		{"old", scheduler.StatusFailed, 48, "stale"},
+	}
+	gdb, err := jobs.db(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range seed {
+		run := models.CronRun{
+			ID:           fmt.Sprintf("run%d", i),
+			JobID:        ids[s.job],
+			Status:       s.status,
+			ScheduledFor: now.Unix() - s.ago*hour,
+		}
+		if s.err != "" {
+			e := s.err
+			run.Error = &e
+		}
+		if err := gdb.Create(&run).Error; err != nil {
+			t.Fatalf("seed run %d: %v", i, err)
+		}
+	}
+
+	tool := NewFailedRunsTool(dsn)
+	tool.now = func() time.Time { return now }
+	out, err := tool.Execute(ctx, map[string]any{"window_hours": float64(24)})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var res struct {
+		Count int `json:"count"`
+		Jobs  []struct {
+			JobID     string `json:"job_id"`
+			Name      string `json:"name"`
+			Failures  int    `json:"failures"`
+			Timeouts  int    `json:"timeouts"`
+			LastError string `json:"last_error"`
+		} `json:"jobs"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("output %q: %v", out, err)
+	}
+	if res.Count != 2 || len(res.Jobs) != 2 {
+		t.Fatalf("got %d jobs, want 2: %s", len(res.Jobs), out)
+	}
+	want := []struct {
+		name      string
+		failures  int
+		timeouts  int
+		lastError string
+	}{
+		{"broken", 3, 1, "timeout"},
+		{"flaky", 2, 0, "newest flaky error"},
+	}
+	for i, w := range want {
+		got := res.Jobs[i]
+		if got.JobID != ids[w.name] || got.Name != w.name || got.Failures != w.failures || got.Timeouts != w.timeouts || got.LastError != w.lastError {
+			t.Fatalf("jobs[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"window_hours": float64(72), "limit": float64(1)})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("output %q: %v", out, err)
+	}
+	if res.Count != 1 || res.Jobs[0].Name != "broken" {
+		t.Fatalf("limited output = %s", out)
+	}
+}
+
+func TestJobTools_ShareOneDBPerDSN(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "jobs.sqlite")
+	a, err := NewScheduleJobTool(dsn).db(ctx)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	b, err := openJobsDB(ctx, NewFailedRunsTool(" "+dsn+" ").dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if a != b {
+		t.Fatalf("schedule_job and failed_runs opened separate pools for the same DSN")
+	}
+	other, err := openJobsDB(ctx, filepath.Join(t.TempDir(), "other.sqlite"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if other == a {
+		t.Fatalf("different DSNs must not share a pool")
+	}
+}
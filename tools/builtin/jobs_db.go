@@ -0,0 +1,53 @@
+package builtin
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/quailyquaily/mistermorph/db"
+	"gorm.io/gorm"
+)
+
+// jobsDB lazily opens and migrates the cron jobs database on first use.
+type jobsDB struct {
+	once sync.Once
+	err  error
+	gdb  *gorm.DB
+}
+
+var (
+	jobsDBsMu sync.Mutex
+	jobsDBs   = map[string]*jobsDB{}
+)
+
+// openJobsDB returns the jobs database for dsn. Tools configured with the same DSN
+// share one connection pool instead of each opening their own.
+func openJobsDB(ctx context.Context, dsn string) (*gorm.DB, error) {
+	dsn = strings.TrimSpace(dsn)
+	jobsDBsMu.Lock()
+	d, ok := jobsDBs[dsn]
+	if !ok {
+		d = &jobsDB{}
+		jobsDBs[dsn] = d
+	}
+	jobsDBsMu.Unlock()
+
+	d.once.Do(func() {
+		cfg := db.DefaultConfig()
+		cfg.DSN = dsn
+		cfg.AutoMigrate = true
+
+		gdb, err := db.Open(ctx, cfg)
+		if err != nil {
+			d.err = err
+			return
+		}
+		if err := db.AutoMigrate(gdb); err != nil {
+			d.err = err
+			return
+		}
+		d.gdb = gdb
+	})
+	return d.gdb, d.err
+}
@@ -29,8 +29,10 @@ func (t *ListJobsTool) ParameterSchema() string {
   "additionalProperties": false,
   "properties": {
     "enabled": { "type": "boolean", "description": "Filter by enabled/disabled." },
+    "type": { "type": "string", "description": "Filter by schedule type: cron (has a cron expression)|interval (has interval_seconds)|run_once (one-shot jobs)." },
     "order_by": { "type": "string", "description": "updated_at_desc|last_run_at_desc|next_run_at_asc (default updated_at_desc)." },
-    "limit": { "type": "integer", "description": "Max results (default 20, max 200)." }
+    "limit": { "type": "integer", "description": "Max results (default 20, max 200)." },
+    "offset": { "type": "integer", "description": "Number of matching jobs to skip, for paging (default 0)." }
   }
 }`
 }
@@ -49,6 +51,13 @@ func (t *ListJobsTool) Execute(ctx context.Context, params map[string]any) (stri
 		limit = 200
 	}
 
+	offset := int(getInt64(params, "offset"))
+	if offset < 0 {
+		return "", fmt.Errorf("offset must be >= 0")
+	}
+
+	jobType := strings.ToLower(strings.TrimSpace(getString(params, "type")))
+
 	orderBy := strings.ToLower(strings.TrimSpace(getString(params, "order_by")))
 	if orderBy == "" {
 		orderBy = "updated_at_desc"
@@ -65,6 +74,17 @@ func (t *ListJobsTool) Execute(ctx context.Context, params map[string]any) (stri
 	if enabledFilter != nil {
 		query = query.Where("enabled = ?", *enabledFilter)
 	}
+	switch jobType {
+	case "":
+	case "cron":
+		query = query.Where("schedule IS NOT NULL")
+	case "interval":
+		query = query.Where("interval_seconds IS NOT NULL")
+	case "run_once":
+		query = query.Where("run_once = ?", true)
+	default:
+		return "", fmt.Errorf("invalid type %q (want cron|interval|run_once)", jobType)
+	}
 	switch orderBy {
 	case "updated_at_desc":
 		query = query.Order("updated_at desc")
@@ -77,7 +97,7 @@ func (t *ListJobsTool) Execute(ctx context.Context, params map[string]any) (stri
 	}
 
 	var jobs []models.CronJob
-	if err := query.Limit(limit).Find(&jobs).Error; err != nil {
+	if err := query.Order("id asc").Offset(offset).Limit(limit).Find(&jobs).Error; err != nil {
 		return "", err
 	}
 
@@ -115,9 +135,15 @@ func (t *ListJobsTool) Execute(ctx context.Context, params map[string]any) (stri
 		}
 		item["updated_at_utc"] = time.Unix(j.UpdatedAt, 0).UTC().Format(time.RFC3339)
 		item["task_preview"] = truncate(j.Task, 200)
+		if j.Params != nil {
+			var params map[string]any
+			if json.Unmarshal([]byte(*j.Params), &params) == nil {
+				item["params"] = params
+			}
+		}
 		out = append(out, item)
 	}
 
-	b, _ := json.Marshal(map[string]any{"ok": true, "count": len(out), "jobs": out})
+	b, _ := json.Marshal(map[string]any{"ok": true, "count": len(out), "offset": offset, "jobs": out})
 	return string(b), nil
 }
@@ -0,0 +1,107 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type listJobsResult struct {
+	Count  int `json:"count"`
+	Offset int `json:"offset"`
+	Jobs   []struct {
+		Name string `json:"name"`
+	} `json:"jobs"`
+}
+
+func TestListJobsTool_TypeFilterAndPaging(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "jobs.sqlite")
+	create := NewScheduleJobTool(dsn)
+	ctx := context.Background()
+	for _, p := range []map[string]any{
+		{"name": "cron-a", "task": "t", "schedule": "0 9 * * *"},
+		{"name": "cron-b", "task": "t", "schedule": "0 18 * * *"},
+		{"name": "every-5m", "task": "t", "interval_seconds": float64(300)},
+		{"name": "every-1h", "task": "t", "interval_seconds": float64(3600)},
+		{"name": "remind-once", "task": "t", "interval_seconds": float64(600), "run_once": true},
+	} {
+		if _, err := create.Execute(ctx, p); err != nil {
+			t.Fatalf("create %v: %v", p["name"], err)
+		}
+	}
+
+	tool := NewListJobsTool(dsn)
+	list := func(t *testing.T, params map[string]any) listJobsResult {
+		t.Helper()
+		out, err := tool.Execute(ctx, params)
+		if err != nil {
+			t.Fatalf("Execute(%v): %v", params, err)
+		}
+		var res listJobsResult
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			t.Fatalf("output %q: %v", out, err)
+		}
+		return res
+	}
+	names := func(res listJobsResult) string {
+		var n []string
+		for _, j := range res.Jobs {
+			n = append(n, j.Name)
+		}
+		sort.Strings(n)
+		return strings.Join(n, ",")
+	}
+
+	cases := []struct {
+		typ  string
+		want string
+	}{
+		{typ: "", want: "cron-a,cron-b,every-1h,every-5m,remind-once"},
+		{typ: "cron", want: "cron-a,cron-b"},
+		{typ: "interval", want: "every-1h,every-5m,remind-once"},
+		{typ: "RUN_ONCE", want: "remind-once"},
+	}
+	for _, tc := range cases {
+		t.Run("type_"+tc.typ, func(t *testing.T) {
+			if got := names(list(t, map[string]any{"type": tc.typ})); got != tc.want {
+				t.Fatalf("type %q: got %s, want %s", tc.typ, got, tc.want)
+			}
+		})
+	}
+
+	t.Run("paging", func(t *testing.T) {
+		seen := map[string]bool{}
+		var pages []int
+		for offset := 0; offset < 6; offset += 2 {
+			res := list(t, map[string]any{"limit": float64(2), "offset": float64(offset)})
+			if res.Offset != offset {
+				t.Fatalf("offset echoed as %d, want %d", res.Offset, offset)
+			}
+			pages = append(pages, res.Count)
+			for _, j := range res.Jobs {
+				if seen[j.Name] {
+					t.Fatalf("job %s returned on two pages", j.Name)
+				}
+				seen[j.Name] = true
+			}
+		}
+		if len(seen) != 5 || pages[0] != 2 || pages[1] != 2 || pages[2] != 1 {
+			t.Fatalf("pages = %v, seen = %v", pages, seen)
+		}
+		if res := list(t, map[string]any{"type": "interval", "limit": float64(2), "offset": float64(2)}); res.Count != 1 {
+			t.Fatalf("last interval page has %d jobs, want 1", res.Count)
+		}
+	})
+
+	for _, params := range []map[string]any{
+		{"type": "weekly"},
+		{"offset": float64(-1)},
+	} {
+		if _, err := tool.Execute(ctx, params); err == nil {
+			t.Fatalf("Execute(%v) succeeded, want error", params)
+		}
+	}
+}
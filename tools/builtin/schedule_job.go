@@ -5,30 +5,39 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/quailyquaily/mistermorph/db"
 	"github.com/quailyquaily/mistermorph/db/models"
 	"gorm.io/gorm"
 )
 
 type ScheduleJobTool struct {
 	DSN string
-
-	once    sync.Once
-	openErr error
-	gdb     *gorm.DB
+	// AllowedModels restricts the per-job model override; empty means any model.
+	AllowedModels []string
 }
 
 func NewScheduleJobTool(dsn string) *ScheduleJobTool {
 	return &ScheduleJobTool{DSN: strings.TrimSpace(dsn)}
 }
 
+func NewScheduleJobToolWithOptions(dsn string, allowedModels []string) *ScheduleJobTool {
+	t := NewScheduleJobTool(dsn)
+	for _, m := range allowedModels {
+		if m = strings.TrimSpace(m); m != "" {
+			t.AllowedModels = append(t.AllowedModels, m)
+		}
+	}
+	return t
+}
+
 func (t *ScheduleJobTool) Name() string { return "schedule_job" }
 func (t *ScheduleJobTool) Description() string {
-	return "Create or update a persistent scheduled job (stored in SQLite cron_jobs). This is run-metadata aware scheduling for the resident scheduler."
+	return "Create or update a persistent scheduled job (stored in SQLite cron_jobs). This is run-metadata aware scheduling for the resident scheduler. " +
+		"Without job_id it upserts by name (name and task required). With job_id it updates only the fields you provide."
 }
 
 func (t *ScheduleJobTool) ParameterSchema() string {
@@ -36,18 +45,19 @@ func (t *ScheduleJobTool) ParameterSchema() string {
   "type": "object",
   "additionalProperties": false,
   "properties": {
-    "name": { "type": "string", "description": "Job name (unique)." },
-    "task": { "type": "string", "description": "Agent task string to execute." },
+    "job_id": { "type": "string", "description": "Existing job id for a partial update; omitted fields are left unchanged." },
+    "name": { "type": "string", "description": "Job name (unique). Required unless job_id is set." },
+    "task": { "type": "string", "description": "Agent task string to execute. Required unless job_id is set." },
+    "params": { "type": ["object", "null"], "description": "Optional structured parameters (e.g. a target chat or dataset id) passed to each run as meta.params. null clears them on update." },
     "enabled": { "type": "boolean", "description": "Enable/disable job (default true)." },
-    "schedule": { "type": "string", "description": "Cron expression (5-field, UTC). Example: \"0 9 * * *\"." },
+    "schedule": { "type": "string", "description": "Cron expression (5-field, UTC). Example: \"0 9 * * *\". Day-of-week also accepts 5L (last Friday) or 1#2 (second Monday)." },
     "interval_seconds": { "type": "integer", "description": "Fixed interval schedule in seconds (alternative to schedule). Note: repeats forever unless run_once=true." },
     "run_once": { "type": "boolean", "description": "If true, disable the job after its next scheduled enqueue (one-shot execution)." },
-    "notify_telegram_chat_id": { "type": "integer", "description": "Optional Telegram chat_id to notify with the run result (best-effort; requires runtime support)." },
+    "notify_telegram_chat_id": { "type": ["integer", "string"], "description": "Optional Telegram chat_id to notify with the run result, as an integer or \"tg:<chat_id>\" (best-effort; requires runtime support). Must be non-zero; null clears it on update." },
     "model": { "type": "string", "description": "Optional model override." },
     "timeout_seconds": { "type": "integer", "description": "Optional per-run timeout override (seconds)." },
     "overlap_policy": { "type": "string", "description": "Overlap policy: forbid|queue|replace (default forbid)." }
-  },
-  "required": ["name", "task"]
+  }
 }`
 }
 
@@ -57,6 +67,18 @@ func (t *ScheduleJobTool) Execute(ctx context.Context, params map[string]any) (s
 		return "", err
 	}
 
+	if err := t.checkModel(getString(params, "model")); err != nil {
+		return "", err
+	}
+
+	if jobID := strings.TrimSpace(getString(params, "job_id")); jobID != "" {
+		job, err := patchCronJob(ctx, gdb, jobID, params)
+		if err != nil {
+			return "", err
+		}
+		return scheduleJobResult(job), nil
+	}
+
 	name := strings.TrimSpace(getString(params, "name"))
 	if name == "" {
 		return "", fmt.Errorf("missing name")
@@ -89,7 +111,15 @@ func (t *ScheduleJobTool) Execute(ctx context.Context, params map[string]any) (s
 		}
 	}
 
-	notifyTelegramChatID := getInt64(params, "notify_telegram_chat_id")
+	notifyTelegramChatID, err := parseTelegramChatID(params["notify_telegram_chat_id"])
+	if err != nil {
+		return "", err
+	}
+
+	jobParams, err := encodeJobParams(params["params"])
+	if err != nil {
+		return "", err
+	}
 
 	model := strings.TrimSpace(getString(params, "model"))
 	timeoutSeconds := getInt64(params, "timeout_seconds")
@@ -107,6 +137,7 @@ func (t *ScheduleJobTool) Execute(ctx context.Context, params map[string]any) (s
 	set := func(j *models.CronJob) {
 		j.Name = name
 		j.Task = task
+		j.Params = jobParams
 		j.Enabled = enabled
 		j.RunOnce = runOnce
 		j.OverlapPolicy = overlapPolicy
@@ -153,6 +184,139 @@ func (t *ScheduleJobTool) Execute(ctx context.Context, params map[string]any) (s
 		}
 	}
 
+	return scheduleJobResult(&job), nil
+}
+
+// patchCronJob applies only the fields present in params to the job with the given id.
+// next_run_at is reset only when the schedule or interval actually changes, so unrelated
+// edits (e.g. rewording the task) don't shift the next run.
+func patchCronJob(ctx context.Context, gdb *gorm.DB, jobID string, params map[string]any) (*models.CronJob, error) {
+	var job models.CronJob
+	if err := gdb.WithContext(ctx).Where("id = ?", jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("job not found")
+		}
+		return nil, err
+	}
+
+	has := func(key string) bool {
+		v, ok := params[key]
+		return ok && v != nil
+	}
+
+	if has("name") {
+		name := strings.TrimSpace(getString(params, "name"))
+		if name == "" {
+			return nil, fmt.Errorf("name must not be empty")
+		}
+		job.Name = name
+	}
+	if has("task") {
+		task := strings.TrimSpace(getString(params, "task"))
+		if task == "" {
+			return nil, fmt.Errorf("task must not be empty")
+		}
+		job.Task = task
+	}
+	if _, ok := params["params"]; ok { // explicit null clears
+		v, err := encodeJobParams(params["params"])
+		if err != nil {
+			return nil, err
+		}
+		job.Params = v
+	}
+	if b, ok := params["enabled"].(bool); ok {
+		job.Enabled = b
+	}
+	if b, ok := params["run_once"].(bool); ok {
+		job.RunOnce = b
+	}
+	if has("overlap_policy") {
+		if p := strings.TrimSpace(getString(params, "overlap_policy")); p != "" {
+			job.OverlapPolicy = p
+		}
+	}
+	if has("model") {
+		if m := strings.TrimSpace(getString(params, "model")); m != "" {
+			job.Model = &m
+		} else {
+			job.Model = nil
+		}
+	}
+	if has("timeout_seconds") {
+		if v := getInt64(params, "timeout_seconds"); v > 0 {
+			job.TimeoutSeconds = &v
+		} else {
+			job.TimeoutSeconds = nil
+		}
+	}
+	if _, ok := params["notify_telegram_chat_id"]; ok { // explicit null clears
+		v, err := parseTelegramChatID(params["notify_telegram_chat_id"])
+		if err != nil {
+			return nil, err
+		}
+		if v != 0 {
+			job.NotifyTelegramChatID = &v
+		} else {
+			job.NotifyTelegramChatID = nil
+		}
+	}
+
+	schedule := strings.TrimSpace(getString(params, "schedule"))
+	intervalSeconds := getInt64(params, "interval_seconds")
+	if schedule != "" && intervalSeconds > 0 {
+		return nil, fmt.Errorf("provide only one of schedule or interval_seconds")
+	}
+	timingChanged := false
+	switch {
+	case schedule != "":
+		timingChanged = job.Schedule == nil || *job.Schedule != schedule || job.IntervalSeconds != nil
+		job.Schedule = &schedule
+		job.IntervalSeconds = nil
+	case intervalSeconds > 0:
+		timingChanged = job.IntervalSeconds == nil || *job.IntervalSeconds != intervalSeconds || job.Schedule != nil
+		job.Schedule = nil
+		job.IntervalSeconds = &intervalSeconds
+	}
+	if timingChanged {
+		// Let the scheduler recompute next_run_at for the new timing.
+		job.NextRunAt = nil
+	}
+
+	if err := gdb.WithContext(ctx).Save(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// maxJobParamsBytes keeps job params well inside the injected run metadata budget.
+const maxJobParamsBytes = 2 * 1024
+
+// encodeJobParams validates the params argument (a JSON object or null) and returns
+// its JSON encoding for CronJob.Params. nil and {} both yield nil.
+func encodeJobParams(v any) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("params must be a JSON object")
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if len(b) > maxJobParamsBytes {
+		return nil, fmt.Errorf("params too large (%d bytes, max %d)", len(b), maxJobParamsBytes)
+	}
+	s := string(b)
+	return &s, nil
+}
+
+func scheduleJobResult(job *models.CronJob) string {
 	out := map[string]any{
 		"ok":       true,
 		"job_id":   job.ID,
@@ -172,27 +336,11 @@ func (t *ScheduleJobTool) Execute(ctx context.Context, params map[string]any) (s
 		}(),
 	}
 	b, _ := json.Marshal(out)
-	return string(b), nil
+	return string(b)
 }
 
 func (t *ScheduleJobTool) db(ctx context.Context) (*gorm.DB, error) {
-	t.once.Do(func() {
-		cfg := db.DefaultConfig()
-		cfg.DSN = t.DSN
-		cfg.AutoMigrate = true
-
-		gdb, err := db.Open(ctx, cfg)
-		if err != nil {
-			t.openErr = err
-			return
-		}
-		if err := db.AutoMigrate(gdb); err != nil {
-			t.openErr = err
-			return
-		}
-		t.gdb = gdb
-	})
-	return t.gdb, t.openErr
+	return openJobsDB(ctx, t.DSN)
 }
 
 func getString(m map[string]any, key string) string {
@@ -208,6 +356,57 @@ func getString(m map[string]any, key string) string {
 	}
 }
 
+// checkModel rejects a model override that is not in AllowedModels, so a typo fails
+// at scheduling time instead of on every run. An empty model clears the override.
+func (t *ScheduleJobTool) checkModel(model string) error {
+	model = strings.TrimSpace(model)
+	if model == "" || len(t.AllowedModels) == 0 {
+		return nil
+	}
+	for _, m := range t.AllowedModels {
+		if m == model {
+			return nil
+		}
+	}
+	return fmt.Errorf("model %q is not allowed (llm.allowed_models: %s)", model, strings.Join(t.AllowedModels, ", "))
+}
+
+// parseTelegramChatID normalizes a notify_telegram_chat_id value. It accepts an
+// integer (JSON number) or a string holding an integer, optionally prefixed with
+// "tg:". nil means "not set" and yields 0; an explicit zero is rejected.
+func parseTelegramChatID(v any) (int64, error) {
+	var id int64
+	switch x := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		id = int64(x)
+	case int64:
+		id = x
+	case float64:
+		if x != math.Trunc(x) || math.Abs(x) > 1<<53 {
+			return 0, fmt.Errorf("invalid notify_telegram_chat_id %v: expected an integer chat id", x)
+		}
+		id = int64(x)
+	case string:
+		str := strings.TrimSpace(x)
+		if len(str) >= 3 && strings.EqualFold(str[:3], "tg:") {
+			str = strings.TrimSpace(str[3:])
+		}
+		n, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid notify_telegram_chat_id %q: expected an integer chat id or \"tg:<chat_id>\"", x)
+		}
+		id = n
+	default:
+		return 0, fmt.Errorf("invalid notify_telegram_chat_id: expected an integer chat id or \"tg:<chat_id>\"")
+	}
+	if id == 0 {
+		return 0, fmt.Errorf("invalid notify_telegram_chat_id: chat id must be non-zero")
+	}
+	return id, nil
+}
+
 func getInt64(m map[string]any, key string) int64 {
 	v, ok := m[key]
 	if !ok || v == nil {
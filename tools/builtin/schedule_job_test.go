@@ -0,0 +1,321 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/mistermorph/db/models"
+)
+
+func createTestJob(t *testing.T, tool *ScheduleJobTool) string {
+	t.Helper()
+	out, err := tool.Execute(context.Background(), map[string]any{
+		"name":            "daily-report",
+		"task":            "write the daily report",
+		"schedule":        "0 9 * * *",
+		"model":           "gpt-4o-mini",
+		"timeout_seconds": float64(120),
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var res struct {
+		JobID string `json:"job_id"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil || res.JobID == "" {
+		t.Fatalf("create output %q: %v", out, err)
+	}
+	return res.JobID
+}
+
+func loadTestJob(t *testing.T, tool *ScheduleJobTool, id string) models.CronJob {
+	t.Helper()
+	gdb, err := tool.db(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job models.CronJob
+	if err := gdb.Where("id = ?", id).First(&job).Error; err != nil {
+		t.Fatalf("load job: %v", err)
+	}
+	return job
+}
+
+func setTestNextRunAt(t *testing.T, tool *ScheduleJobTool, id string, v int64) {
+	t.Helper()
+	gdb, _ := tool.db(context.Background())
+	if err := gdb.Model(&models.CronJob{}).Where("id = ?", id).Update("next_run_at", v).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScheduleJobTool_PartialUpdate(t *testing.T) {
+	tool := NewScheduleJobTool(filepath.Join(t.TempDir(), "jobs.sqlite"))
+	id := createTestJob(t, tool)
+	const next = int64(1_900_000_000)
+
+	cases := []struct {
+		name      string
+		params    map[string]any
+		check     func(t *testing.T, j models.CronJob)
+		resetNext bool
+	}{
+		{
+			name:   "task_only",
+			params: map[string]any{"task": "write the weekly report"},
+			check: func(t *testing.T, j models.CronJob) {
+				if j.Task != "write the weekly report" || j.Name != "daily-report" {
+					t.Fatalf("task/name = %q/%q", j.Task, j.Name)
+				}
+				if j.Schedule == nil || *j.Schedule != "0 9 * * *" || j.Model == nil || *j.Model != "gpt-4o-mini" || j.TimeoutSeconds == nil || *j.TimeoutSeconds != 120 {
+					t.Fatalf("untouched fields changed: %+v", j)
+				}
+			},
+		},
+		{
+			name:   "same_schedule",
+			params: map[string]any{"schedule": "0 9 * * *"},
+			check:  func(t *testing.T, j models.CronJob) {},
+		},
+		{
+			name:      "schedule_changed",
+			params:    map[string]any{"schedule": "30 8 * * 1-5"},
+			resetNext: true,
+			check: func(t *testing.T, j models.CronJob) {
+				if j.Schedule == nil || *j.Schedule != "30 8 * * 1-5" || j.Task != "write the weekly report" {
+					t.Fatalf("job = %+v", j)
+				}
+			},
+		},
+		{
+			name:      "switch_to_interval",
+			params:    map[string]any{"interval_seconds": float64(3600)},
+			resetNext: true,
+			check: func(t *testing.T, j models.CronJob) {
+				if j.Schedule != nil || j.IntervalSeconds == nil || *j.IntervalSeconds != 3600 {
+					t.Fatalf("job = %+v", j)
+				}
+			},
+		},
+		{
+			name:   "clear_model",
+			params: map[string]any{"model": ""},
+			check: func(t *testing.T, j models.CronJob) {
+				if j.Model != nil || j.TimeoutSeconds == nil {
+					t.Fatalf("job = %+v", j)
+				}
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			setTestNextRunAt(t, tool, id, next)
+			params := map[string]any{"job_id": id}
+			for k, v := range tc.params {
+				params[k] = v
+			}
+			if _, err := tool.Execute(context.Background(), params); err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			j := loadTestJob(t, tool, id)
+			tc.check(t, j)
+			if tc.resetNext {
+				if j.NextRunAt != nil {
+					t.Fatalf("next_run_at = %v, want reset", *j.NextRunAt)
+				}
+			} else if j.NextRunAt == nil || *j.NextRunAt != next {
+				t.Fatalf("next_run_at changed for non-timing update: %v", j.NextRunAt)
+			}
+		})
+	}
+}
+
+func TestScheduleJobTool_PartialUpdateErrors(t *testing.T) {
+	tool := NewScheduleJobTool(filepath.Join(t.TempDir(), "jobs.sqlite"))
+	id := createTestJob(t, tool)
+
+	cases := []struct {
+		name    string
+		params  map[string]any
+		wantErr string
+	}{
+		{name: "unknown_id", params: map[string]any{"job_id": "nope", "task": "x"}, wantErr: "job not found"},
+		{name: "empty_task", params: map[string]any{"job_id": id, "task": "  "}, wantErr: "task must not be empty"},
+		{name: "both_timings", params: map[string]any{"job_id": id, "schedule": "* * * * *", "interval_seconds": float64(60)}, wantErr: "only one of"},
+		{name: "upsert_requires_name", params: map[string]any{"task": "x", "schedule": "* * * * *"}, wantErr: "missing name"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tool.Execute(context.Background(), tc.params)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTelegramChatID(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      any
+		want    int64
+		wantErr bool
+	}{
+		{name: "absent", in: nil, want: 0},
+		{name: "json_number", in: float64(123456), want: 123456},
+		{name: "negative_group", in: float64(-1001234567890), want: -1001234567890},
+		{name: "plain_string", in: " 42 ", want: 42},
+		{name: "tg_prefix", in: "tg:-100987", want: -100987},
+		{name: "tg_prefix_upper", in: "TG: 77", want: 77},
+		{name: "zero", in: float64(0), wantErr: true},
+		{name: "zero_string", in: "tg:0", wantErr: true},
+		{name: "fractional", in: 1.5, wantErr: true},
+		{name: "garbage", in: "chat-42", wantErr: true},
+		{name: "bad_type", in: true, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseTelegramChatID(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %d", got)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Fatalf("got %d, %v; want %d", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestScheduleJobTool_NotifyTelegramChatID(t *testing.T) {
+	tool := NewScheduleJobTool(filepath.Join(t.TempDir(), "jobs.sqlite"))
+	base := func(notify any) map[string]any {
+		return map[string]any{"name": "notify-job", "task": "ping", "schedule": "0 9 * * *", "notify_telegram_chat_id": notify}
+	}
+
+	if _, err := tool.Execute(context.Background(), base("tg:-1001")); err != nil {
+		t.Fatalf("tg form: %v", err)
+	}
+	var job models.CronJob
+	gdb, _ := tool.db(context.Background())
+	if err := gdb.Where("name = ?", "notify-job").First(&job).Error; err != nil {
+		t.Fatal(err)
+	}
+	if job.NotifyTelegramChatID == nil || *job.NotifyTelegramChatID != -1001 {
+		t.Fatalf("stored chat id = %v", job.NotifyTelegramChatID)
+	}
+
+	for _, bad := range []any{float64(0), "nope"} {
+		_, err := tool.Execute(context.Background(), base(bad))
+		if err == nil || !strings.Contains(err.Error(), "notify_telegram_chat_id") {
+			t.Fatalf("notify %v: err = %v", bad, err)
+		}
+		_, err = tool.Execute(context.Background(), map[string]any{"job_id": job.ID, "notify_telegram_chat_id": bad})
+		if err == nil || !strings.Contains(err.Error(), "notify_telegram_chat_id") {
+			t.Fatalf("patch notify %v: err = %v", bad, err)
+		}
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"job_id": job.ID, "notify_telegram_chat_id": float64(555)}); err != nil {
+		t.Fatalf("patch plain int: %v", err)
+	}
+	if got := loadTestJob(t, tool, job.ID); got.NotifyTelegramChatID == nil || *got.NotifyTelegramChatID != 555 {
+		t.Fatalf("patched chat id = %v", got.NotifyTelegramChatID)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"job_id": job.ID, "notify_telegram_chat_id": nil}); err != nil {
+		t.Fatalf("patch clear: %v", err)
+	}
+	if got := loadTestJob(t, tool, job.ID); got.NotifyTelegramChatID != nil {
+		t.Fatalf("chat id not cleared: %v", *got.NotifyTelegramChatID)
+	}
+}
+
+func TestScheduleJobTool_AllowedModels(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "jobs.sqlite")
+	params := func(model string) map[string]any {
+		return map[string]any{"name": "m-" + model, "task": "t", "schedule": "0 9 * * *", "model": model}
+	}
+
+	restricted := NewScheduleJobToolWithOptions(dsn, []string{"gpt-4o-mini", " gpt-4o ", ""})
+	if _, err := restricted.Execute(context.Background(), params("gpt-4o")); err != nil {
+		t.Fatalf("allowed model: %v", err)
+	}
+	_, err := restricted.Execute(context.Background(), params("gpt-4o-mnii"))
+	if err == nil || !strings.Contains(err.Error(), `model "gpt-4o-mnii" is not allowed`) {
+		t.Fatalf("unknown model: err = %v", err)
+	}
+	id := createTestJob(t, restricted)
+	if _, err := restricted.Execute(context.Background(), map[string]any{"job_id": id, "model": "typo-model"}); err == nil {
+		t.Fatalf("partial update with unknown model should fail")
+	}
+	if _, err := restricted.Execute(context.Background(), map[string]any{"job_id": id, "model": ""}); err != nil {
+		t.Fatalf("clearing the model override: %v", err)
+	}
+
+	unrestricted := NewScheduleJobToolWithOptions(filepath.Join(t.TempDir(), "jobs.sqlite"), nil)
+	if _, err := unrestricted.Execute(context.Background(), params("anything-goes")); err != nil {
+		t.Fatalf("unrestricted: %v", err)
+	}
+}
+
+func TestScheduleJobTool_Params(t *testing.T) {
+	tool := NewScheduleJobTool(filepath.Join(t.TempDir(), "jobs.sqlite"))
+	ctx := context.Background()
+	out, err := tool.Execute(ctx, map[string]any{
+		"name":     "dataset-sync",
+		"task":     "sync the dataset",
+		"schedule": "0 * * * *",
+		"params":   map[string]any{"dataset_id": "ds-42", "limit": float64(10)},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var res struct {
+		JobID string `json:"job_id"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil || res.JobID == "" {
+		t.Fatalf("create output %q: %v", out, err)
+	}
+
+	cases := []struct {
+		name    string
+		patch   map[string]any
+		want    string // stored JSON; "" means unset
+		wantErr string
+	}{
+		{name: "created", want: `{"dataset_id":"ds-42","limit":10}`},
+		{name: "other_field_keeps_params", patch: map[string]any{"task": "sync it again"}, want: `{"dataset_id":"ds-42","limit":10}`},
+		{name: "replace", patch: map[string]any{"params": map[string]any{"chat": "ops"}}, want: `{"chat":"ops"}`},
+		{name: "not_object", patch: map[string]any{"params": "chat=ops"}, wantErr: "params must be a JSON object", want: `{"chat":"ops"}`},
+		{name: "too_large", patch: map[string]any{"params": map[string]any{"blob": strings.Repeat("x", maxJobParamsBytes)}}, wantErr: "params too large", want: `{"chat":"ops"}`},
+		{name: "null_clears", patch: map[string]any{"params": nil}, want: ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.patch != nil {
+				tc.patch["job_id"] = res.JobID
+				_, err := tool.Execute(ctx, tc.patch)
+				if tc.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+						t.Fatalf("err = %v, want %q", err, tc.wantErr)
+					}
+				} else if err != nil {
+					t.Fatalf("patch: %v", err)
+				}
+			}
+			job := loadTestJob(t, tool, res.JobID)
+			got := ""
+			if job.Params != nil {
+				got = *job.Params
+			}
+			if got != tc.want {
+				t.Fatalf("params = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 )
 
+// Registry is safe for concurrent use, so a shared base registry can be
+// snapshotted by concurrent runs while tools are still being registered.
 type Registry struct {
+	mu    sync.RWMutex
 	tools map[string]Tool
 }
 
@@ -15,19 +19,37 @@ func NewRegistry() *Registry {
 }
 
 func (r *Registry) Register(tool Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.tools[tool.Name()] = tool
 }
 
 func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	t, ok := r.tools[name]
 	return t, ok
 }
 
+// Snapshot returns an independent registry holding the tools registered at the time of the call.
+// Use it to derive per-run registries from a shared base.
+func (r *Registry) Snapshot() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := &Registry{tools: make(map[string]Tool, len(r.tools))}
+	for name, t := range r.tools {
+		out.tools[name] = t
+	}
+	return out
+}
+
 func (r *Registry) All() []Tool {
+	r.mu.RLock()
 	out := make([]Tool, 0, len(r.tools))
 	for _, t := range r.tools {
 		out = append(out, t)
 	}
+	r.mu.RUnlock()
 	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
 	return out
 }
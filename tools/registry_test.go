@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type namedTool string
+
+func (t namedTool) Name() string            { return string(t) }
+func (t namedTool) Description() string     { return "" }
+func (t namedTool) ParameterSchema() string { return "{}" }
+func (t namedTool) Execute(context.Context, map[string]any) (string, error) {
+	return "", nil
+}
+
+func TestRegistrySnapshot_Independent(t *testing.T) {
+	base := NewRegistry()
+	base.Register(namedTool("a"))
+
+	snap := base.Snapshot()
+	snap.Register(namedTool("per_run"))
+	base.Register(namedTool("b"))
+
+	if _, ok := base.Get("per_run"); ok {
+		t.Fatalf("snapshot registration leaked into base")
+	}
+	if _, ok := snap.Get("b"); ok {
+		t.Fatalf("base registration after snapshot leaked into snapshot")
+	}
+	if got := snap.ToolNames(); got != "a, per_run" {
+		t.Fatalf("snapshot tools = %q", got)
+	}
+}
+
+// Run with -race: concurrent per-run snapshots must be safe while the base is still being mutated.
+func TestRegistrySnapshot_Concurrent(t *testing.T) {
+	base := NewRegistry()
+	base.Register(namedTool("seed"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				base.Register(namedTool(fmt.Sprintf("tool_%d_%d", i, j)))
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				run := base.Snapshot()
+				run.Register(namedTool(fmt.Sprintf("run_%d", i)))
+				if _, ok := run.Get("seed"); !ok {
+					t.Errorf("snapshot missing seed tool")
+					return
+				}
+				_ = run.All()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := len(base.All()); n != 1+8*100 {
+		t.Fatalf("base tools = %d, want %d", n, 1+8*100)
+	}
+}